@@ -1,12 +1,16 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"texashold-backend/hand"
 	"texashold-backend/montecarlo"
+	"texashold-backend/preflop"
+	"texashold-backend/ranges"
 )
 
 func cors(w http.ResponseWriter) {
@@ -23,20 +27,114 @@ func writeJSON(w http.ResponseWriter, status int, v interface{}) {
 	}
 }
 
-func parseCardsStrings(ss []string) ([]hand.Card, error) {
-	var all []hand.Card
-	for _, s := range ss {
-		s = trimSpace(s)
-		if s == "" {
-			continue
+// cardStrings converts cards to strings in format f.
+func cardStrings(cards []hand.Card, f hand.CardFormat) []string {
+	out := make([]string, len(cards))
+	for i := range cards {
+		out[i] = cards[i].Format(f)
+	}
+	return out
+}
+
+// hiLoShares converts a montecarlo hi/lo result for the response.
+func hiLoShares(r montecarlo.HiLoResult) *HiLoShares {
+	return &HiLoShares{Scoop: r.Scoop, HighHalf: r.HighHalf, LowHalf: r.LowHalf, Equity: r.Equity}
+}
+
+// equityStats converts a player's simulated or exact estimates.
+func equityStats(p montecarlo.PlayerEquity) *EquityStats {
+	est := func(e montecarlo.Estimate) Estimate {
+		return Estimate{Value: e.Value, StdErr: e.StdErr, CILow: e.Low, CIHigh: e.High}
+	}
+	return &EquityStats{Win: est(p.Win), Tie: est(p.Tie), Equity: est(p.Equity)}
+}
+
+// handTypeFrequencies converts a player's made-hand distribution: the hand types made on
+// the turn or the river, from High Card up.
+func handTypeFrequencies(h montecarlo.HandTypeStats) []HandTypeFrequency {
+	var out []HandTypeFrequency
+	for t := hand.HighCard; t <= hand.FiveOfAKind; t++ {
+		f := HandTypeFrequency{HandType: t.String(), River: h.River[t], Equity: h.Equity[t], WinRate: h.WinRate(t)}
+		made := f.River > 0
+		if h.Turn != nil {
+			turn := h.Turn[t]
+			f.Turn = &turn
+			made = made || turn > 0
 		}
-		c, err := hand.ParseCard(s)
-		if err != nil {
-			return nil, err
+		if made {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// bestHand returns the best five cards, the same five as played (wild cards replaced by
+// what they stand for) and their value.
+func bestHand(game hand.Variant, wilds hand.Wilds, hole, board []hand.Card) (best, played []hand.Card, val hand.HandValue) {
+	if wilds.None() {
+		best, val = game.BestHand(hole, board)
+		return best, best, val
+	}
+	return game.BestWildHand(hole, board, wilds)
+}
+
+// winningCards returns the cards of best that make up val, judged by how they are played.
+func winningCards(best, played []hand.Card, val hand.HandValue, hole []hand.Card) []hand.Card {
+	win := hand.WinningCards(played, val, hole)
+	used := make([]bool, len(played))
+	out := make([]hand.Card, 0, len(win))
+	for _, c := range win {
+		for i, p := range played {
+			if !used[i] && p == c {
+				used[i] = true
+				out = append(out, best[i])
+				break
+			}
 		}
-		all = append(all, c)
 	}
-	return all, nil
+	return out
+}
+
+// compareLows compares two Razz hands, which win with the best ace-to-five low.
+func compareLows(game hand.Variant, format hand.CardFormat, h1, h2 []hand.Card) CompareResponse {
+	best1, best2 := game.BestLowHand(h1, nil), game.BestLowHand(h2, nil)
+	low1, low2 := game.EvaluateLow(h1, nil), game.EvaluateLow(h2, nil)
+	desc1, desc2 := hand.DescribeLow(best1), hand.DescribeLow(best2)
+	resp := CompareResponse{
+		Hand1Best:         cardStrings(best1, format),
+		Hand1WinningCards: cardStrings(best1, format),
+		Hand1Type:         "Low",
+		Hand2Best:         cardStrings(best2, format),
+		Hand2WinningCards: cardStrings(best2, format),
+		Hand2Type:         "Low",
+		Winner:            "tie",
+		Hand1Description:  desc1,
+		Hand2Description:  desc2,
+		Explanation: CompareExplanation{
+			Step:       -1,
+			Hand1Cards: cardStrings(best1, format),
+			Hand2Cards: cardStrings(best2, format),
+			Text:       "tie: both have " + desc1,
+		},
+	}
+	switch {
+	case low1 > low2:
+		resp.Winner, resp.Explanation.Step = "hand1", 0
+		resp.Explanation.Text = fmt.Sprintf("hand1's %s beats hand2's %s", desc1, desc2)
+	case low1 < low2:
+		resp.Winner, resp.Explanation.Step = "hand2", 0
+		resp.Explanation.Text = fmt.Sprintf("hand2's %s beats hand1's %s", desc2, desc1)
+	}
+	return resp
+}
+
+// holeCountText describes how many hole cards game needs, e.g. "exactly 2 hole cards".
+func holeCountText(game hand.Variant) string {
+	min, max := game.HoleCards()
+	if min == max {
+		return fmt.Sprintf("exactly %d hole cards", min)
+	}
+	return fmt.Sprintf("%d or %d hole cards", min, max)
 }
 
 func trimSpace(s string) string {
@@ -56,40 +154,51 @@ func HandleEvaluate(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != "POST" {
-		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed"})
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
 		return
 	}
 	var req EvaluateRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON"})
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON", Code: CodeInvalidJSON})
 		return
 	}
-	hole, err := parseCardsStrings(req.HoleCards)
-	if err != nil || len(hole) != 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Need exactly 2 hole cards"})
+	v := newValidator()
+	game := v.variant("game", req.Game)
+	format := v.cardFormat("card_format", req.CardFormat)
+	wilds := v.wildCards(req.Jokers, req.WildRanks)
+	hole := v.holeCards("hole_cards", req.HoleCards)
+	comm := v.board("community_cards", req.CommunityCards, boardCounts(game, true)...)
+	if !v.ok() {
+		v.writeErrors(w)
 		return
 	}
-	comm, err := parseCardsStrings(req.CommunityCards)
-	if err != nil || len(comm) != 5 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Need exactly 5 community cards"})
+	if game.Lowball() {
+		low := game.BestLowHand(hole, comm)
+		writeJSON(w, http.StatusOK, EvaluateResponse{
+			BestHand:     cardStrings(low, format),
+			WinningCards: cardStrings(low, format),
+			HandType:     "Low",
+			HandValue:    hand.DescribeLow(low),
+		})
 		return
 	}
-	all := append(append([]hand.Card(nil), hole...), comm...)
-	best, val := hand.BestHand(all)
-	bestStrs := make([]string, len(best))
-	for i := range best {
-		bestStrs[i] = best[i].String()
-	}
-	winning := hand.WinningCards(best, val, hole)
-	winningStrs := make([]string, len(winning))
-	for i := range winning {
-		winningStrs[i] = winning[i].String()
+	best, played, val := bestHand(game, wilds, hole, comm)
+	winning := winningCards(best, played, val, hole)
+	strength, rules := game.EvaluateWild(hole, comm, wilds), game.Rules()
+	var playedStrs []string
+	if !wilds.None() {
+		playedStrs = cardStrings(played, format)
 	}
 	writeJSON(w, http.StatusOK, EvaluateResponse{
-		BestHand:     bestStrs,
-		WinningCards: winningStrs,
+		BestHand:     cardStrings(best, format),
+		PlayedHand:   playedStrs,
+		WinningCards: cardStrings(winning, format),
 		HandType:     val.Type.String(),
-		HandValue:    val.Type.String(),
+		HandValue:    val.Describe(),
+		LowHand:      cardStrings(game.BestLowHand(hole, comm), format),
+		HandRank:     rules.Class(strength),
+		HandsBeating: rules.BeatenBy(strength),
+		Percentile:   rules.Percentile(strength),
 	})
 }
 
@@ -100,72 +209,84 @@ func HandleCompare(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != "POST" {
-		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed"})
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
 		return
 	}
 	var req CompareRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON"})
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON", Code: CodeInvalidJSON})
 		return
 	}
-	h1Hole, err := parseCardsStrings(req.Hand1.HoleCards)
-	if err != nil || len(h1Hole) != 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Hand1: need exactly 2 hole cards"})
+	// Both hands usually share the board, so each board is checked against both
+	// players' hole cards but not against the other board.
+	v := newValidator()
+	game := v.variant("game", req.Game)
+	format := v.cardFormat("card_format", req.CardFormat)
+	wilds := v.wildCards(req.Jokers, req.WildRanks)
+	h1Hole := v.holeCards("hand1.hole_cards", req.Hand1.HoleCards)
+	h2Hole := v.holeCards("hand2.hole_cards", req.Hand2.HoleCards)
+	holes := v.seenCards()
+	h1Comm := v.board("hand1.community_cards", req.Hand1.CommunityCards, boardCounts(game, true)...)
+	v.setSeen(holes)
+	h2Comm := v.board("hand2.community_cards", req.Hand2.CommunityCards, boardCounts(game, true)...)
+	if !v.ok() {
+		v.writeErrors(w)
 		return
 	}
-	h1Comm, err := parseCardsStrings(req.Hand1.CommunityCards)
-	if err != nil || len(h1Comm) != 5 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Hand1: need exactly 5 community cards"})
+	if game.Lowball() {
+		writeJSON(w, http.StatusOK, compareLows(game, format, h1Hole, h2Hole))
 		return
 	}
-	h2Hole, err := parseCardsStrings(req.Hand2.HoleCards)
-	if err != nil || len(h2Hole) != 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Hand2: need exactly 2 hole cards"})
-		return
-	}
-	h2Comm, err := parseCardsStrings(req.Hand2.CommunityCards)
-	if err != nil || len(h2Comm) != 5 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Hand2: need exactly 5 community cards"})
-		return
-	}
-	h1All := append(append([]hand.Card(nil), h1Hole...), h1Comm...)
-	h2All := append(append([]hand.Card(nil), h2Hole...), h2Comm...)
-	best1, val1 := hand.BestHand(h1All)
-	best2, val2 := hand.BestHand(h2All)
-	cmp := hand.CompareHands(best1, best2)
+	best1, played1, val1 := bestHand(game, wilds, h1Hole, h1Comm)
+	best2, played2, val2 := bestHand(game, wilds, h2Hole, h2Comm)
+	s1, s2 := game.EvaluateWild(h1Hole, h1Comm, wilds), game.EvaluateWild(h2Hole, h2Comm, wilds)
 	winner := "tie"
-	if cmp > 0 {
+	if s1 > s2 {
 		winner = "hand1"
-	} else if cmp < 0 {
+	} else if s1 < s2 {
 		winner = "hand2"
 	}
-	best1Strs := make([]string, len(best1))
-	for i := range best1 {
-		best1Strs[i] = best1[i].String()
-	}
-	best2Strs := make([]string, len(best2))
-	for i := range best2 {
-		best2Strs[i] = best2[i].String()
-	}
-	win1 := hand.WinningCards(best1, val1, h1Hole)
-	win2 := hand.WinningCards(best2, val2, h2Hole)
-	win1Strs := make([]string, len(win1))
-	for i := range win1 {
-		win1Strs[i] = win1[i].String()
-	}
-	win2Strs := make([]string, len(win2))
-	for i := range win2 {
-		win2Strs[i] = win2[i].String()
-	}
-	writeJSON(w, http.StatusOK, CompareResponse{
-		Hand1Best:       best1Strs,
-		Hand1WinningCards: win1Strs,
-		Hand1Type:       val1.Type.String(),
-		Hand2Best:       best2Strs,
-		Hand2WinningCards: win2Strs,
-		Hand2Type:       val2.Type.String(),
-		Winner:          winner,
-	})
+	win1 := winningCards(best1, played1, val1, h1Hole)
+	win2 := winningCards(best2, played2, val2, h2Hole)
+	expl := game.Rules().Explain(played1, val1, played2, val2)
+	resp := CompareResponse{
+		Hand1Best:         cardStrings(best1, format),
+		Hand1WinningCards: cardStrings(win1, format),
+		Hand1Type:         val1.Type.String(),
+		Hand2Best:         cardStrings(best2, format),
+		Hand2WinningCards: cardStrings(win2, format),
+		Hand2Type:         val2.Type.String(),
+		Winner:            winner,
+		Hand1Description:  expl.DescriptionA,
+		Hand2Description:  expl.DescriptionB,
+		Explanation: CompareExplanation{
+			Step:       expl.Step,
+			Kicker:     expl.Kicker,
+			Hand1Cards: cardStrings(expl.CardsA, format),
+			Hand2Cards: cardStrings(expl.CardsB, format),
+			Text:       expl.Text("hand1", "hand2"),
+		},
+	}
+	if !wilds.None() {
+		resp.Hand1Played = cardStrings(played1, format)
+		resp.Hand2Played = cardStrings(played2, format)
+	}
+	if game.HiLo() {
+		low1, low2 := game.EvaluateLow(h1Hole, h1Comm), game.EvaluateLow(h2Hole, h2Comm)
+		resp.Hand1Low = cardStrings(game.BestLowHand(h1Hole, h1Comm), format)
+		resp.Hand2Low = cardStrings(game.BestLowHand(h2Hole, h2Comm), format)
+		switch {
+		case low1 == 0 && low2 == 0:
+			resp.LowWinner = "none"
+		case low1 > low2:
+			resp.LowWinner = "hand1"
+		case low1 < low2:
+			resp.LowWinner = "hand2"
+		default:
+			resp.LowWinner = "tie"
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // HandleWinProbability handles POST /api/win-probability
@@ -175,95 +296,460 @@ func HandleWinProbability(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if r.Method != "POST" {
-		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed"})
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
 		return
 	}
 	var req WinProbabilityRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON"})
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON", Code: CodeInvalidJSON})
 		return
 	}
-	hole, err := parseCardsStrings(req.HoleCards)
-	if err != nil || len(hole) != 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Need exactly 2 hole cards"})
-		return
+	v := newValidator()
+	game := v.variant("game", req.Game)
+	wilds := v.wildCards(req.Jokers, req.WildRanks)
+	var hole []hand.Card
+	if game.Stud() {
+		hole = v.countedCards("hole_cards", req.HoleCards, 1, 7)
+	} else {
+		hole = v.holeCards("hole_cards", req.HoleCards)
+	}
+	comm := v.board("community_cards", req.CommunityCards, boardCounts(game, false)...)
+	dead := v.deadCards("dead_cards", req.DeadCards)
+	switch {
+	case req.NumPlayers < 2:
+		v.add("num_players", CodeOutOfRange, "num_players must be at least 2, got %d", req.NumPlayers)
+	case game.Stud():
+		v.deckSize("num_players", req.NumPlayers, 7*req.NumPlayers+len(dead))
+	default:
+		v.deckSize("num_players", req.NumPlayers, 5+len(hole)*req.NumPlayers)
+	}
+	if len(req.OpponentRanges) > req.NumPlayers-1 && req.NumPlayers >= 2 {
+		v.add("opponent_ranges", CodeCardCount, "%d opponents cannot have %d ranges", req.NumPlayers-1, len(req.OpponentRanges))
+	}
+	// Opponents without a range get a random hand.
+	players := make([]montecarlo.Player, max(req.NumPlayers, 1+len(req.OpponentRanges)))
+	players[0].Hole = hole
+	ranged := false
+	for i, s := range req.OpponentRanges {
+		if trimSpace(s) != "" {
+			players[i+1].Range = v.handRange(fmt.Sprintf("opponent_ranges[%d]", i), s)
+			ranged = true
+		}
 	}
-	comm, err := parseCardsStrings(req.CommunityCards)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid community cards"})
-		return
+	adaptive := v.stopping(req.TargetMargin, req.MaxDurationMS)
+	v.handTypes(req.HandTypes)
+	method := v.method(req.Method, req.ExactThreshold, req.NumSimulations, adaptive)
+	if ranged && method == MethodExact {
+		v.add("method", CodeUnsupported, "exact enumeration does not support hand ranges")
 	}
-	if len(comm) != 0 && len(comm) != 3 && len(comm) != 4 && len(comm) != 5 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Community cards must be 0, 3, 4, or 5"})
+	sim := v.simulator(req.Seed, req.RNG)
+	sim.Sampling = v.sampling(req.Sampling, ranged, req.HandTypes)
+	if !v.ok() {
+		v.writeErrors(w)
 		return
 	}
-	if req.NumPlayers < 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "num_players must be at least 2"})
+	nSims := numSimulations(req.NumSimulations, adaptive)
+	ctx, cancel := simContext(r, req.MaxDurationMS)
+	defer cancel()
+	remaining := v.deck().Count() - len(hole) - len(comm)
+	combos := montecarlo.ExactCombinations(remaining, 5-len(comm), len(hole), req.NumPlayers-1)
+	exact := useExact(method, req.ExactThreshold, combos, wilds.None() && !game.Stud() && !ranged)
+	table := !exact && !ranged && req.NumPlayers-1 <= preflop.MaxOpponents && preflopTable(method, game, wilds, comm, req.HandTypes, adaptive, sim.Sampling)
+	resp := WinProbabilityResponse{
+		Method:     MethodMonteCarlo,
+		Seed:       &sim.Seed,
+		RNG:        sim.RNG.String(),
+		StopReason: string(montecarlo.StopCompleted),
+	}
+	if game.HiLo() {
+		var res montecarlo.HiLoResult
+		if exact && runExact(ctx, w, nSims, func(ctx context.Context) (err error) {
+			res, err = montecarlo.ExactWinProbabilityHiLo(ctx, game, hole, comm, req.NumPlayers)
+			return err
+		}) {
+			resp.Method, resp.Combinations = MethodExact, int64(combos)
+		} else if exact && nSims == 0 {
+			return
+		} else {
+			var sims int
+			var stop montecarlo.StopReason
+			res, sims, stop = sim.WinProbabilityHiLo(ctx, game, hole, comm, req.NumPlayers, nSims)
+			resp.Simulations, resp.StopReason = sims, string(stop)
+		}
+		resp.WinProbability, resp.TieProbability = res.Scoop, res.Split
+		resp.Description = fmt.Sprintf("Scoop: %s  Split: %s  Equity: %s", formatPercent(res.Scoop), formatPercent(res.Split), formatPercent(res.Equity))
+		resp.HiLo = hiLoShares(res)
+		resp.Stats = equityStats(res.Stats)
+		writeJSON(w, http.StatusOK, resp)
 		return
 	}
-	if req.NumSimulations <= 0 || req.NumSimulations > 500000 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "num_simulations must be 1 to 500000"})
+	var res montecarlo.Result
+	if game.Stud() {
+		// Opponents' cards are all unknown.
+		studs := make([]hand.StudHand, req.NumPlayers)
+		studs[0].Down = hole
+		res = sim.StudWinProbability(ctx, game, studs, dead, nSims)
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+	} else if exact && runExact(ctx, w, nSims, func(ctx context.Context) (err error) {
+		res, err = montecarlo.ExactWinProbability(ctx, game, hole, comm, req.NumPlayers, req.HandTypes)
+		return err
+	}) {
+		resp.Method, resp.Combinations = MethodExact, int64(combos)
+	} else if exact && nSims == 0 {
 		return
-	}
-	winProb, tieProb := montecarlo.WinProbability(hole, comm, req.NumPlayers, req.NumSimulations)
-	writeJSON(w, http.StatusOK, WinProbabilityResponse{
-		WinProbability: winProb,
-		TieProbability: tieProb,
-		Description:    fmt.Sprintf("Win: %s  Tie: %s", formatPercent(winProb), formatPercent(tieProb)),
-	})
+	} else if table {
+		res = preflopVsRandom(hole, req.NumPlayers-1)
+		resp.Method, resp.Simulations = MethodTable, res.Sims
+		resp.Seed, resp.RNG = nil, ""
+	} else if ranged {
+		sim.TargetMargin, sim.HandTypes = req.TargetMargin, req.HandTypes
+		var err error
+		if res, err = sim.RangeEquity(ctx, game, wilds, players, comm, nSims); err != nil {
+			v.add("opponent_ranges", CodeImpossibleRanges, "no deal fits every opponent's range at once")
+			v.writeErrors(w)
+			return
+		}
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+		resp.Sampling, resp.EffectiveSampleSize, resp.Variance = res.Sampling.String(), res.ESS, res.Variance
+	} else {
+		sim.TargetMargin, sim.HandTypes = req.TargetMargin, req.HandTypes
+		res = sim.WinProbability(ctx, game, wilds, hole, comm, req.NumPlayers, nSims)
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+		resp.Sampling, resp.EffectiveSampleSize, resp.Variance = res.Sampling.String(), res.ESS, res.Variance
+	}
+	p := res.Players[0]
+	resp.WinProbability, resp.TieProbability = p.Win.Value, p.Tie.Value
+	resp.Stats = equityStats(p)
+	if req.HandTypes {
+		resp.HandTypes = handTypeFrequencies(res.HandTypes[0])
+	}
+	resp.Description = fmt.Sprintf("Win: %s  Tie: %s", formatPercent(p.Win.Value), formatPercent(p.Tie.Value))
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // HandleWinProbabilityMulti handles POST /api/win-probability-multi
-// One simulation with all players' hole cards; win% + tie% sum to 100%.
+// One simulation with all players' hole cards; the equities sum to 100%.
 func HandleWinProbabilityMulti(w http.ResponseWriter, r *http.Request) {
+	winProbabilityMulti(w, r, nil)
+}
+
+// HandleWinProbabilityMultiStream handles POST /api/win-probability-multi/stream
+// The same request as /api/win-probability-multi, answered as Server-Sent Events:
+// "progress" events with the estimates so far while simulating, then a "result" event
+// with the response. A client that disconnects stops the simulation.
+func HandleWinProbabilityMultiStream(w http.ResponseWriter, r *http.Request) {
+	winProbabilityMulti(w, r, &eventStream{w: w})
+}
+
+// winProbabilityMulti serves both multi-player endpoints; stream is nil for the JSON one.
+func winProbabilityMulti(w http.ResponseWriter, r *http.Request, stream *eventStream) {
 	cors(w)
 	if r.Method == "OPTIONS" {
 		return
 	}
 	if r.Method != "POST" {
-		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed"})
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
 		return
 	}
 	var req WinProbabilityMultiRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON"})
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON", Code: CodeInvalidJSON})
 		return
 	}
+	v := newValidator()
+	game := v.variant("game", req.Game)
+	wilds := v.wildCards(req.Jokers, req.WildRanks)
 	if len(req.Players) < 2 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Need at least 2 players"})
+		v.add("players", CodeCardCount, "need at least 2 players, got %d", len(req.Players))
+	}
+	adaptive := v.stopping(req.TargetMargin, req.MaxDurationMS)
+	v.handTypes(req.HandTypes)
+	method := v.method(req.Method, req.ExactThreshold, req.NumSimulations, adaptive)
+	sim := v.simulator(req.Seed, req.RNG)
+	if stream != nil {
+		v.intRange("progress_every", req.ProgressEvery, 0, MaxSimulations)
+		v.intRange("progress_interval_ms", req.ProgressIntervalMS, 0, 60000)
+	} else if req.ProgressEvery != 0 || req.ProgressIntervalMS != 0 {
+		v.add("progress_every", CodeUnsupported, "progress events are only sent by /api/win-probability-multi/stream")
+	}
+	holes := make([][]hand.Card, len(req.Players))
+	studs := make([]hand.StudHand, len(req.Players))
+	players := make([]montecarlo.Player, len(req.Players))
+	nHole, nRanged := 0, 0
+	for i, p := range req.Players {
+		path := fmt.Sprintf("players[%d]", i)
+		if game.Stud() {
+			studs[i].Down = v.countedCards(path+".hole_cards", p.HoleCards, 0, 3)
+			studs[i].Up = v.countedCards(path+".up_cards", p.UpCards, 0, 4)
+			continue
+		}
+		if len(p.UpCards) > 0 {
+			v.add(path+".up_cards", CodeUnsupported, "%s has no up cards; they are only used in stud games", game)
+		}
+		if trimSpace(p.Range) != "" {
+			if len(p.HoleCards) > 0 {
+				v.add(path+".range", CodeInvalidRange, "a player has hole_cards or a range, not both")
+			}
+			nRanged++
+			continue
+		}
+		holes[i] = v.holeCards(path+".hole_cards", p.HoleCards)
+		players[i].Hole = holes[i]
+		nHole += len(holes[i])
+	}
+	comm := v.board("community_cards", req.CommunityCards, boardCounts(game, false)...)
+	dead := v.deadCards("dead_cards", req.DeadCards)
+	// Ranges go last: they lose the combos every known card blocks.
+	for i, p := range req.Players {
+		if !game.Stud() && trimSpace(p.Range) != "" {
+			players[i].Range = v.handRange(fmt.Sprintf("players[%d].range", i), p.Range)
+		}
+	}
+	if nRanged > 0 && method == MethodExact {
+		v.add("method", CodeUnsupported, "exact enumeration does not support hand ranges")
+	}
+	sim.Sampling = v.sampling(req.Sampling, nRanged > 0, req.HandTypes)
+	if game.Stud() {
+		v.deckSize("players", len(req.Players), 7*len(req.Players)+len(dead))
+	} else {
+		v.deckSize("players", len(req.Players), 5+nHole+2*nRanged)
+	}
+	if !v.ok() {
+		v.writeErrors(w)
 		return
 	}
-	if req.NumSimulations <= 0 || req.NumSimulations > 500000 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "num_simulations must be 1 to 500000"})
+	nSims := numSimulations(req.NumSimulations, adaptive)
+	ctx, cancel := simContext(r, req.MaxDurationMS)
+	defer cancel()
+	resp := WinProbabilityMultiResponse{
+		Players:    make([]WinProbabilityMultiPlayer, len(req.Players)),
+		Method:     MethodMonteCarlo,
+		Seed:       &sim.Seed,
+		RNG:        sim.RNG.String(),
+		StopReason: string(montecarlo.StopCompleted),
+	}
+	respond := func(resp WinProbabilityMultiResponse) {
+		if stream != nil {
+			stream.send("result", resp)
+		} else {
+			writeJSON(w, http.StatusOK, resp)
+		}
+	}
+	if stream != nil {
+		sim.Progress = stream.progress(req.ProgressEvery, req.ProgressIntervalMS, func(res montecarlo.Result) interface{} {
+			return EquityProgress{Simulations: res.Sims, Margin: res.Margin(), Players: multiPlayers(res, req.HandTypes)}
+		})
+	}
+	remaining := v.deck().Count() - nHole - len(comm)
+	combos := montecarlo.ExactCombinations(remaining, 5-len(comm), 0, 0)
+	exact := useExact(method, req.ExactThreshold, combos, wilds.None() && nRanged == 0)
+	var tableRes montecarlo.Result
+	table := false
+	if preflopTable(method, game, wilds, comm, req.HandTypes, adaptive, sim.Sampling) {
+		tableRes, table = preflopHeadsUp(players)
+	}
+	if game.HiLo() {
+		var res []montecarlo.HiLoResult
+		if exact && runExact(ctx, w, nSims, func(ctx context.Context) (err error) {
+			res, err = montecarlo.ExactWinProbabilityMultiHiLo(ctx, game, holes, comm)
+			return err
+		}) {
+			resp.Method, resp.Combinations = MethodExact, int64(combos)
+		} else if exact && nSims == 0 {
+			return
+		} else {
+			var sims int
+			var stop montecarlo.StopReason
+			res, sims, stop = sim.WinProbabilityMultiHiLo(ctx, game, holes, comm, nSims)
+			resp.Simulations, resp.StopReason = sims, string(stop)
+		}
+		for i := range res {
+			resp.Players[i].WinProbability = res[i].Scoop
+			resp.Players[i].TieProbability = res[i].Split
+			resp.Players[i].Equity = res[i].Equity
+			resp.Players[i].HiLo = hiLoShares(res[i])
+			resp.Players[i].Stats = equityStats(res[i].Stats)
+		}
+		respond(resp)
 		return
 	}
-	comm, err := parseCardsStrings(req.CommunityCards)
-	if err != nil {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid community cards"})
+	var res montecarlo.Result
+	if game.Stud() {
+		res = sim.StudWinProbability(ctx, game, studs, dead, nSims)
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+	} else if exact && runExact(ctx, w, nSims, func(ctx context.Context) (err error) {
+		res, err = montecarlo.ExactWinProbabilityMulti(ctx, game, holes, comm, req.HandTypes)
+		return err
+	}) {
+		resp.Method, resp.Combinations = MethodExact, int64(combos)
+	} else if exact && nSims == 0 {
+		return
+	} else if table {
+		res = tableRes
+		resp.Method, resp.Simulations = MethodTable, res.Sims
+		resp.Seed, resp.RNG = nil, ""
+	} else if nRanged > 0 {
+		sim.TargetMargin, sim.HandTypes = req.TargetMargin, req.HandTypes
+		var err error
+		if res, err = sim.RangeEquity(ctx, game, wilds, players, comm, nSims); err != nil {
+			v.add("players", CodeImpossibleRanges, "no deal fits every player's range at once")
+			v.writeErrors(w)
+			return
+		}
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+		resp.Sampling, resp.EffectiveSampleSize, resp.Variance = res.Sampling.String(), res.ESS, res.Variance
+	} else {
+		sim.TargetMargin, sim.HandTypes = req.TargetMargin, req.HandTypes
+		res = sim.WinProbabilityMulti(ctx, game, wilds, holes, comm, nSims)
+		resp.Simulations, resp.StopReason = res.Sims, string(res.Stop)
+		resp.Sampling, resp.EffectiveSampleSize, resp.Variance = res.Sampling.String(), res.ESS, res.Variance
+	}
+	resp.Players = multiPlayers(res, req.HandTypes)
+	respond(resp)
+}
+
+// multiPlayers converts the players of a high-hand Result, with their made-hand
+// distributions when handTypes is set.
+func multiPlayers(res montecarlo.Result, handTypes bool) []WinProbabilityMultiPlayer {
+	players := make([]WinProbabilityMultiPlayer, len(res.Players))
+	for i, p := range res.Players {
+		players[i].WinProbability = p.Win.Value
+		players[i].TieProbability = p.Tie.Value
+		players[i].Equity = p.Equity.Value
+		players[i].Stats = equityStats(p)
+		if handTypes {
+			players[i].HandTypes = handTypeFrequencies(res.HandTypes[i])
+		}
+	}
+	return players
+}
+
+func formatPercent(p float64) string {
+	return fmt.Sprintf("%.2f%%", p*100)
+}
+
+// HandleOuts handles POST /api/outs
+// Every unseen card of a flop or turn with what it does for each player: the hand type
+// it makes, whether it is an out, and whether the out is tainted; runner-runner pairs
+// too on the flop.
+func HandleOuts(w http.ResponseWriter, r *http.Request) {
+	cors(w)
+	if r.Method == "OPTIONS" {
 		return
 	}
-	if len(comm) != 0 && len(comm) != 3 && len(comm) != 4 && len(comm) != 5 {
-		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Community cards must be 0, 3, 4, or 5"})
+	if r.Method != "POST" {
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
 		return
 	}
+	var req OutsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Invalid JSON", Code: CodeInvalidJSON})
+		return
+	}
+	v := newValidator()
+	game := v.variant("game", req.Game)
+	format := v.cardFormat("card_format", req.CardFormat)
+	wilds := v.wildCards(req.Jokers, req.WildRanks)
+	if game.Stud() || game.HiLo() {
+		v.add("game", CodeUnsupported, "outs are only computed for high-hand board games, not %s", game)
+	}
+	if len(req.Players) < 2 {
+		v.add("players", CodeCardCount, "need at least 2 players, got %d", len(req.Players))
+	}
 	holes := make([][]hand.Card, len(req.Players))
 	for i, p := range req.Players {
-		hole, err := parseCardsStrings(p.HoleCards)
-		if err != nil || len(hole) != 2 {
-			writeJSON(w, http.StatusBadRequest, ErrorResponse{Error: "Each player needs exactly 2 hole cards"})
-			return
+		holes[i] = v.holeCards(fmt.Sprintf("players[%d].hole_cards", i), p.HoleCards)
+	}
+	comm := v.board("community_cards", req.CommunityCards, 3, 4)
+	if !v.ok() {
+		v.writeErrors(w)
+		return
+	}
+	res, err := montecarlo.Outs(game, wilds, holes, comm)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{Error: err.Error()})
+		return
+	}
+	resp := OutsResponse{Players: make([]OutsPlayer, len(res.Players)), Cards: make([]OutsCard, len(res.Cards))}
+	for i, p := range res.Players {
+		resp.Players[i] = OutsPlayer{
+			HandType:    p.Type.String(),
+			Ahead:       p.Ahead,
+			Outs:        cardStrings(p.Outs, format),
+			TaintedOuts: cardStrings(p.Tainted, format),
+		}
+		for _, rr := range p.RunnerRunner {
+			resp.Players[i].RunnerRunner = append(resp.Players[i].RunnerRunner, RunnerOuts{Cards: cardStrings(rr.Cards[:], format), HandType: rr.Type.String()})
 		}
-		holes[i] = hole
 	}
-	winFracs, tieFrac := montecarlo.WinProbabilityMulti(holes, comm, req.NumSimulations)
-	resp := WinProbabilityMultiResponse{Players: make([]WinProbabilityMultiPlayer, len(winFracs))}
-	for i := range winFracs {
-		resp.Players[i].WinProbability = winFracs[i]
-		resp.Players[i].TieProbability = tieFrac
+	for k, c := range res.Cards {
+		effects := make([]OutsCardEffect, len(c.Players))
+		for i, e := range c.Players {
+			effects[i] = OutsCardEffect{HandType: e.Type.String(), Improves: e.Improves, Ahead: e.Ahead, Out: e.Out, Tainted: e.Tainted}
+		}
+		resp.Cards[k] = OutsCard{Card: c.Card.Format(format), Players: effects}
 	}
 	writeJSON(w, http.StatusOK, resp)
 }
 
-func formatPercent(p float64) string {
-	return fmt.Sprintf("%.2f%%", p*100)
+// HandlePreflopGrid handles GET /api/preflop-grid?opponents=1 or ?vs=AKs
+// The equity of every starting hand from the preflop tables, as a 13x13 grid.
+func HandlePreflopGrid(w http.ResponseWriter, r *http.Request) {
+	cors(w)
+	if r.Method == "OPTIONS" {
+		return
+	}
+	if r.Method != "GET" {
+		writeJSON(w, http.StatusMethodNotAllowed, ErrorResponse{Error: "Method not allowed", Code: CodeMethodNotAllowed})
+		return
+	}
+	v := newValidator()
+	q := r.URL.Query()
+	opponents := 1
+	if s := q.Get("opponents"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil {
+			v.add("opponents", CodeOutOfRange, "opponents must be a number, got %q", s)
+		} else {
+			v.intRange("opponents", n, 1, preflop.MaxOpponents)
+			opponents = n
+		}
+	}
+	var vs ranges.Class
+	headsUp := q.Get("vs") != ""
+	if headsUp {
+		rg, err := ranges.Parse(q.Get("vs"))
+		ok := err == nil
+		if ok {
+			vs, ok = preflop.ClassOf(rg)
+		}
+		if !ok {
+			v.add("vs", CodeInvalidRange, "vs must be one starting hand such as AKs, TT or 72o, got %q", q.Get("vs"))
+		}
+		if opponents != 1 {
+			v.add("opponents", CodeUnsupported, "vs is heads-up: one opponent")
+		}
+	}
+	if !v.ok() {
+		v.writeErrors(w)
+		return
+	}
+	t := preflop.Embedded()
+	resp := PreflopGridResponse{Opponents: opponents, Simulations: t.RandomSims()}
+	for rank := hand.RankA; rank >= hand.Rank2; rank-- {
+		resp.Ranks = append(resp.Ranks, hand.Card{Rank: rank, Suit: hand.SuitSpade}.Format(hand.RankFirst)[:1])
+	}
+	if headsUp {
+		resp.Vs, resp.Simulations = vs.String(), t.HeadsUpSims()
+	}
+	resp.Grid = preflopGrid(func(c ranges.Class) PreflopCell {
+		var p montecarlo.PlayerEquity
+		if headsUp {
+			p = t.HeadsUp(c, vs)
+		} else {
+			p, _ = t.VsRandom(c, opponents)
+		}
+		return PreflopCell{Hand: c.String(), Win: p.Win.Value, Tie: p.Tie.Value, Equity: p.Equity.Value}
+	})
+	writeJSON(w, http.StatusOK, resp)
 }
@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"texashold-backend/montecarlo"
+)
+
+// Simulation methods in WinProbabilityRequest.Method and the responses.
+const (
+	MethodAuto       = "auto"        // exact at or below the combination threshold, else Monte Carlo
+	MethodExact      = "exact"       // enumerate every runout
+	MethodMonteCarlo = "monte_carlo" // sample num_simulations random runouts
+	MethodTable      = "table"       // responses only: "auto" answered from the preflop tables
+)
+
+// Error codes of a forced exact computation that fails with no num_simulations to fall
+// back on.
+const (
+	CodeExactTimeout   = "exact_timeout"   // it ran out of time (503)
+	CodeExactCancelled = "exact_cancelled" // the client went away (503)
+	CodeExactFailed    = "exact_failed"    // it rejected its input (500)
+)
+
+// ExactThreshold is the largest number of runouts the "auto" method enumerates exactly;
+// a request's exact_threshold overrides it. main sets it from EXACT_THRESHOLD.
+// 2,000,000 covers heads-up preflop with both hands known (1,712,304 boards).
+var ExactThreshold int64 = 2_000_000
+
+// ExactTimeBudget bounds an exact computation. Past it "auto" falls back to Monte Carlo.
+var ExactTimeBudget = 5 * time.Second
+
+// MaxSimulations is the most simulations a request may run, and the number run when
+// target_margin or max_duration_ms is set without num_simulations.
+const MaxSimulations = 500000
+
+// stopping checks the adaptive stopping options and reports whether one is set. Only
+// the high-hand simulations (not hi/lo or stud) stop early.
+func (v *validator) stopping(targetMargin float64, maxDurationMS int) bool {
+	if targetMargin < 0 || targetMargin > 0.5 {
+		v.add("target_margin", CodeOutOfRange, "target_margin must be 0 to 0.5, got %g", targetMargin)
+	}
+	v.intRange("max_duration_ms", maxDurationMS, 0, 60000)
+	adaptive := targetMargin != 0 || maxDurationMS != 0
+	if adaptive && (v.game.HiLo() || v.game.Stud()) {
+		v.add("target_margin", CodeUnsupported, "%s simulations do not stop early; set num_simulations", v.game)
+	}
+	return adaptive
+}
+
+// handTypes checks the hand_types option: only the high-hand games collect it.
+func (v *validator) handTypes(handTypes bool) {
+	if handTypes && (v.game.HiLo() || v.game.Stud()) {
+		v.add("hand_types", CodeUnsupported, "%s does not report hand types", v.game)
+	}
+}
+
+// numSimulations returns the simulations to run: n, or MaxSimulations when n is unset
+// and the run stops adaptively.
+func numSimulations(n int, adaptive bool) int {
+	if n == 0 && adaptive {
+		return MaxSimulations
+	}
+	return n
+}
+
+// simContext returns the context of a simulation: the request's, so a client that
+// leaves stops it, limited to maxDurationMS when set.
+func simContext(r *http.Request, maxDurationMS int) (context.Context, context.CancelFunc) {
+	if maxDurationMS > 0 {
+		return context.WithTimeout(r.Context(), time.Duration(maxDurationMS)*time.Millisecond)
+	}
+	return context.WithCancel(r.Context())
+}
+
+// method checks the requested method and threshold, and whether num_simulations is
+// needed: always except for a forced exact computation or an adaptive run.
+func (v *validator) method(method string, threshold int64, numSimulations int, adaptive bool) string {
+	switch method {
+	case "":
+		method = MethodAuto
+	case MethodAuto, MethodExact, MethodMonteCarlo:
+	default:
+		v.add("method", CodeOutOfRange, "method must be %q, %q or %q, got %q", MethodAuto, MethodExact, MethodMonteCarlo, method)
+	}
+	if threshold < 0 {
+		v.add("exact_threshold", CodeOutOfRange, "exact_threshold must not be negative, got %d", threshold)
+	}
+	if method == MethodExact && (!v.wilds.None() || v.game.Stud()) {
+		v.add("method", CodeUnsupported, "exact enumeration does not support wild cards or stud games")
+	}
+	if method != MethodExact && !adaptive || numSimulations != 0 {
+		v.intRange("num_simulations", numSimulations, 1, MaxSimulations)
+	}
+	return method
+}
+
+// useExact reports whether a computation of combos runouts should be exact.
+// exactable is false for games only Monte Carlo handles (wild cards, stud).
+func useExact(method string, threshold int64, combos float64, exactable bool) bool {
+	if !exactable {
+		return false
+	}
+	if threshold == 0 {
+		threshold = ExactThreshold
+	}
+	return method == MethodExact || method == MethodAuto && combos <= float64(threshold)
+}
+
+// runExact runs f within ExactTimeBudget and ctx (the request's simulation context).
+// It reports whether f finished; if not and the request cannot fall back to Monte Carlo
+// (no num_simulations), it has already written the error response.
+func runExact(ctx context.Context, w http.ResponseWriter, numSimulations int, f func(ctx context.Context) error) bool {
+	ctx, cancel := context.WithTimeout(ctx, ExactTimeBudget)
+	defer cancel()
+	err := f(ctx)
+	if err == nil {
+		return true
+	}
+	if numSimulations != 0 {
+		return false
+	}
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Error: "exact enumeration exceeded the time budget; set num_simulations to fall back to Monte Carlo",
+			Code:  CodeExactTimeout,
+		})
+	case errors.Is(err, context.Canceled):
+		writeJSON(w, http.StatusServiceUnavailable, ErrorResponse{
+			Error: "exact enumeration was cancelled",
+			Code:  CodeExactCancelled,
+		})
+	default:
+		// Validation should have caught the input, so this is the server's fault.
+		writeJSON(w, http.StatusInternalServerError, ErrorResponse{
+			Error: "exact enumeration failed: " + err.Error(),
+			Code:  CodeExactFailed,
+		})
+	}
+	return false
+}
+
+// sampling checks the requested sampling strategy. Stratified and antithetic sampling
+// need every player's hole cards, importance sampling a range; the others only sample
+// plainly, as do hi/lo, stud and hand-type runs.
+func (v *validator) sampling(s string, ranged, handTypes bool) montecarlo.Sampling {
+	g, err := montecarlo.ParseSampling(s)
+	switch {
+	case err != nil:
+		v.add("sampling", CodeOutOfRange, "%v", err)
+	case g == montecarlo.PlainSampling:
+	case v.game.HiLo() || v.game.Stud():
+		v.add("sampling", CodeUnsupported, "%s simulations only sample plainly", v.game)
+	case handTypes:
+		v.add("sampling", CodeUnsupported, "hand types are only collected with plain sampling")
+	case g == montecarlo.ImportanceSampling && !ranged:
+		v.add("sampling", CodeUnsupported, "importance sampling needs a player with a range")
+	case g != montecarlo.ImportanceSampling && ranged:
+		v.add("sampling", CodeUnsupported, "%s sampling does not take hand ranges", g)
+	}
+	return g
+}
+
+// simulator returns the Simulator of a request: its seed, or a new random one, and
+// the named RNG.
+func (v *validator) simulator(seed *uint64, rng string) montecarlo.Simulator {
+	g, err := montecarlo.ParseRNG(rng)
+	if err != nil {
+		v.add("rng", CodeOutOfRange, "%v", err)
+	}
+	sim := montecarlo.Simulator{RNG: g}
+	if seed != nil {
+		sim.Seed = *seed
+	} else {
+		sim.Seed = montecarlo.NewSeed()
+	}
+	return sim
+}
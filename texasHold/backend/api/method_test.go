@@ -0,0 +1,421 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"texashold-backend/montecarlo"
+	"texashold-backend/preflop"
+)
+
+func TestMethodSelection(t *testing.T) {
+	tests := []struct {
+		body         string
+		method       string
+		combinations int64
+	}{
+		// Two known hands on the flop: 990 runouts.
+		{`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 100}`, MethodExact, 990},
+		// No num_simulations needed when exact is forced. The turn keeps it well inside
+		// ExactTimeBudget even under the race detector; montecarlo counts preflop's boards.
+		{`{"players": [{"hole_cards": ["HA", "SA"]}, {"hole_cards": ["DK", "CK"]}], "community_cards": ["H9", "H5", "C2", "D7"], "method": "exact"}`, MethodExact, 44},
+		{`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 100, "method": "monte_carlo"}`, MethodMonteCarlo, 0},
+		{`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 100, "exact_threshold": 500}`, MethodMonteCarlo, 0},
+		{`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 100, "jokers": 1}`, MethodMonteCarlo, 0},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(tt.body)))
+		var resp WinProbabilityMultiResponse
+		if rec.Code != http.StatusOK || json.NewDecoder(rec.Body).Decode(&resp) != nil {
+			t.Errorf("%s: status %d", tt.body, rec.Code)
+			continue
+		}
+		if resp.Method != tt.method || resp.Combinations != tt.combinations {
+			t.Errorf("%s: method %q combinations %d, want %q %d", tt.body, resp.Method, resp.Combinations, tt.method, tt.combinations)
+		}
+	}
+
+	// One random opponent on the river: 990 hands.
+	rec := httptest.NewRecorder()
+	HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"hole_cards": ["HA", "HK"], "community_cards": ["HQ", "HJ", "D2", "C3", "S7"], "num_players": 2, "num_simulations": 100}`)))
+	var resp WinProbabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Method != MethodExact || resp.Combinations != 990 {
+		t.Errorf("river: %+v err %v", resp, err)
+	}
+
+	code, errResp := post(t, HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2, "method": "exact", "wild_ranks": ["2"]}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "method" || errResp.Fields[0].Code != CodeUnsupported {
+		t.Errorf("exact with wilds: status %d %+v", code, errResp.Fields)
+	}
+	code, errResp = post(t, HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "num_simulations" {
+		t.Errorf("auto without num_simulations: status %d %+v", code, errResp.Fields)
+	}
+}
+
+func TestSeededRequests(t *testing.T) {
+	run := func(body string) WinProbabilityResponse {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		var resp WinProbabilityResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d err %v", body, rec.Code, err)
+		}
+		return resp
+	}
+	// Preflop "auto" requests are answered from the tables; simulate.
+	const body = `{"hole_cards": ["HA", "HK"], "community_cards": [], "num_players": 3, "num_simulations": 5000, "method": "monte_carlo", "seed": 12345%s}`
+	for _, rng := range []string{"", `, "rng": "chacha8"`} {
+		a, b := run(fmt.Sprintf(body, rng)), run(fmt.Sprintf(body, rng))
+		if !reflect.DeepEqual(a, b) || a.Seed == nil || *a.Seed != 12345 || a.Method != MethodMonteCarlo {
+			t.Errorf("rng %q: %+v and %+v", rng, a, b)
+		}
+	}
+	// Without a seed the response reports the one picked, which reproduces the result.
+	a := run(`{"hole_cards": ["HA", "HK"], "community_cards": [], "num_players": 3, "num_simulations": 5000, "method": "monte_carlo"}`)
+	b := run(fmt.Sprintf(`{"hole_cards": ["HA", "HK"], "community_cards": [], "num_players": 3, "num_simulations": 5000, "method": "monte_carlo", "seed": %d}`, *a.Seed))
+	if !reflect.DeepEqual(a, b) || a.RNG != "pcg" {
+		t.Errorf("replayed seed: %+v and %+v", a, b)
+	}
+
+	code, resp := post(t, HandleWinProbabilityMulti, `{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": [], "num_simulations": 10, "rng": "dice"}`)
+	if code != http.StatusUnprocessableEntity || resp.Fields[0].Path != "rng" {
+		t.Errorf("unknown rng: status %d %+v", code, resp.Fields)
+	}
+}
+
+func TestAdaptiveRequests(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"hole_cards": ["HA", "HK"], "community_cards": [], "num_players": 3, "method": "monte_carlo", "target_margin": 0.01, "seed": 1}`)))
+	var resp WinProbabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("target_margin: status %d err %v", rec.Code, err)
+	}
+	if resp.StopReason != "target_margin" || resp.Simulations >= MaxSimulations || resp.Stats == nil {
+		t.Fatalf("target_margin: %+v", resp)
+	}
+	if eq := resp.Stats.Equity; eq.CIHigh-eq.CILow > 0.02 || eq.CILow > eq.Value || eq.Value > eq.CIHigh {
+		t.Errorf("equity interval %+v", eq)
+	}
+
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}, {"hole_cards": ["DQ", "DJ"]}], "community_cards": [], "max_duration_ms": 1}`)))
+	var multi WinProbabilityMultiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&multi); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("max_duration_ms: status %d err %v", rec.Code, err)
+	}
+	if multi.StopReason != "max_duration" || multi.Simulations == 0 || multi.Simulations >= MaxSimulations || multi.Players[2].Stats == nil {
+		t.Errorf("max_duration_ms: %+v", multi)
+	}
+
+	code, errResp := post(t, HandleWinProbability, `{"game": "omaha-hilo", "hole_cards": ["HA", "H2", "S3", "SK"], "num_players": 2, "target_margin": 0.01}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Code != CodeUnsupported {
+		t.Errorf("hi/lo target_margin: status %d %+v", code, errResp.Fields)
+	}
+	code, errResp = post(t, HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2, "target_margin": 0.9}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "target_margin" {
+		t.Errorf("target_margin 0.9: status %d %+v", code, errResp.Fields)
+	}
+}
+
+func TestMultiEquity(t *testing.T) {
+	// Players 0 and 1 chop the river; 2 and 3 did not tie.
+	for _, method := range []string{MethodExact, MethodMonteCarlo} {
+		rec := httptest.NewRecorder()
+		HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(
+			`{"players": [{"hole_cards": ["HJ", "HT"]}, {"hole_cards": ["DJ", "DT"]}, {"hole_cards": ["H9", "H8"]}, {"hole_cards": ["C9", "C8"]}],
+			  "community_cards": ["SA", "SK", "SQ", "D3", "C2"], "num_simulations": 100, "method": %q}`, method))))
+		var resp WinProbabilityMultiResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d err %v", method, rec.Code, err)
+		}
+		for i, want := range []float64{0.5, 0.5, 0, 0} {
+			p := resp.Players[i]
+			if p.Equity != want || p.TieProbability != want*2 || p.WinProbability != 0 || p.Stats.Equity.Value != want {
+				t.Errorf("%s: player %d %+v", method, i, p)
+			}
+		}
+	}
+
+	// Stud players report their own ties and equity too.
+	rec := httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"game": "razz", "players": [{"up_cards": ["HA"]}, {"up_cards": ["SA"]}, {"up_cards": ["DK"]}], "num_simulations": 2000}`)))
+	var resp WinProbabilityMultiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("razz: status %d err %v", rec.Code, err)
+	}
+	equity := 0.0
+	for _, p := range resp.Players {
+		equity += p.Equity
+	}
+	if math.Abs(equity-1) > 1e-9 || resp.Players[2].Stats == nil {
+		t.Errorf("razz: %+v", resp.Players)
+	}
+
+	// Hi/lo players' stats are their scoops, split pots and equity.
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"game": "omaha-hilo", "players": [{"hole_cards": ["HA", "H2", "S3", "SK"]}, {"hole_cards": ["SQ", "DQ", "HJ", "DJ"]}], "community_cards": [], "num_simulations": 2000, "method": "monte_carlo"}`)))
+	resp = WinProbabilityMultiResponse{}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("hi/lo: status %d err %v", rec.Code, err)
+	}
+	for i, p := range resp.Players {
+		if p.Stats == nil || p.Stats.Win.Value != p.WinProbability || p.Stats.Equity.Value != p.Equity || p.Stats.Equity.StdErr == 0 {
+			t.Errorf("hi/lo: player %d %+v %+v", i, p, p.Stats)
+		}
+	}
+}
+
+func TestHandTypeRequests(t *testing.T) {
+	for _, method := range []string{MethodExact, MethodMonteCarlo} {
+		rec := httptest.NewRecorder()
+		HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(
+			`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C6"],
+			  "num_simulations": 20000, "method": %q, "hand_types": true, "seed": 1}`, method))))
+		var resp WinProbabilityMultiResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d err %v", method, rec.Code, err)
+		}
+		for i, p := range resp.Players {
+			var turn, river, equity float64
+			for _, f := range p.HandTypes {
+				if f.Turn == nil || f.River > 0 && math.Abs(f.WinRate-f.Equity/f.River) > 1e-9 {
+					t.Errorf("%s: player %d %+v", method, i, f)
+					continue
+				}
+				turn += *f.Turn
+				river += f.River
+				equity += f.Equity
+			}
+			if math.Abs(turn-1) > 1e-9 || math.Abs(river-1) > 1e-9 || math.Abs(equity-p.Equity) > 1e-9 {
+				t.Errorf("%s: player %d turn %v river %v equity %v of %v", method, i, turn, river, equity, p.Equity)
+			}
+		}
+		if types := resp.Players[0].HandTypes; len(types) == 0 || types[0].HandType != "High Card" {
+			t.Errorf("%s: %+v", method, types)
+		}
+	}
+
+	rec := httptest.NewRecorder()
+	HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"hole_cards": ["HA", "HK"], "community_cards": ["H9", "H5", "C6", "D2"], "num_players": 3, "num_simulations": 1000, "hand_types": true}`)))
+	var resp WinProbabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("single: status %d err %v", rec.Code, err)
+	}
+	if len(resp.HandTypes) == 0 || resp.HandTypes[0].Turn != nil {
+		t.Errorf("single: %+v", resp.HandTypes)
+	}
+
+	code, errResp := post(t, HandleWinProbability, `{"game": "razz", "hole_cards": ["HA", "H2", "S3"], "num_players": 2, "num_simulations": 10, "hand_types": true}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "hand_types" {
+		t.Errorf("razz hand_types: status %d %+v", code, errResp.Fields)
+	}
+}
+
+func TestSamplingRequests(t *testing.T) {
+	const known = `"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}, {"hole_cards": ["DQ", "DJ"]}], "community_cards": ["H9", "H5", "C2"]`
+	const ranged = `"players": [{"hole_cards": ["HA", "HK"]}, {"range": "QQ+, AKs"}, {"range": "JJ+, AQs"}], "community_cards": ["H9", "H5", "C2"]`
+	tests := []struct {
+		body, sampling string
+	}{
+		{known, ""},
+		{known, "stratified"},
+		{known, "antithetic"},
+		{ranged, "importance"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(
+			`{%s, "num_simulations": 10000, "method": "monte_carlo", "sampling": %q, "seed": 1}`, tt.body, tt.sampling))))
+		var resp WinProbabilityMultiResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%q: status %d err %v", tt.sampling, rec.Code, err)
+		}
+		want := tt.sampling
+		if want == "" {
+			want = "plain"
+		}
+		if resp.Sampling != want || resp.EffectiveSampleSize <= 0 || resp.Variance <= 0 || resp.Simulations < 10000 {
+			t.Errorf("%q: sampling %q, ESS %v, variance %v, %d sims", tt.sampling, resp.Sampling, resp.EffectiveSampleSize, resp.Variance, resp.Simulations)
+		}
+		// The variance is the largest of the equity estimates'.
+		for i, p := range resp.Players {
+			if se := p.Stats.Equity.StdErr; se*se > resp.Variance*(1+1e-9) {
+				t.Errorf("%q: player %d standard error %v, variance %v", tt.sampling, i, se, resp.Variance)
+			}
+		}
+	}
+
+	for _, tt := range []struct {
+		body, code string
+	}{
+		{fmt.Sprintf(`{%s, "num_simulations": 100, "sampling": "quasi"}`, known), CodeOutOfRange},
+		{fmt.Sprintf(`{%s, "num_simulations": 100, "sampling": "importance"}`, known), CodeUnsupported},
+		{fmt.Sprintf(`{%s, "num_simulations": 100, "sampling": "stratified"}`, ranged), CodeUnsupported},
+		{fmt.Sprintf(`{%s, "num_simulations": 100, "sampling": "antithetic", "hand_types": true}`, known), CodeUnsupported},
+	} {
+		code, errResp := post(t, HandleWinProbabilityMulti, tt.body)
+		if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "sampling" || errResp.Fields[0].Code != tt.code {
+			t.Errorf("%s: status %d %+v", tt.body, code, errResp.Fields)
+		}
+	}
+
+	const single = `"hole_cards": ["HA", "HK"], "num_players": 3, "num_simulations": 10000, "seed": 1`
+	for _, tt := range []struct {
+		body, sampling string
+	}{
+		{`"community_cards": ["H9", "H5", "C2"]`, "stratified"},
+		{`"community_cards": []`, "antithetic"},
+		{`"community_cards": [], "opponent_ranges": ["QQ+, AKs"]`, "importance"},
+	} {
+		rec := httptest.NewRecorder()
+		HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(fmt.Sprintf(
+			`{%s, %s, "sampling": %q}`, single, tt.body, tt.sampling))))
+		var resp WinProbabilityResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("single %q: status %d err %v", tt.sampling, rec.Code, err)
+		}
+		if resp.Method != MethodMonteCarlo || resp.Sampling != tt.sampling || resp.EffectiveSampleSize <= 0 || resp.Simulations < 10000 {
+			t.Errorf("single %q: method %q, sampling %q, ESS %v, %d sims", tt.sampling, resp.Method, resp.Sampling, resp.EffectiveSampleSize, resp.Simulations)
+		}
+	}
+	code, errResp := post(t, HandleWinProbability, fmt.Sprintf(`{%s, "community_cards": [], "sampling": "importance"}`, single))
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "sampling" || errResp.Fields[0].Code != CodeUnsupported {
+		t.Errorf("single importance without ranges: status %d %+v", code, errResp.Fields)
+	}
+}
+
+func TestPreflopRequests(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"hole_cards": ["SA", "DA"], "community_cards": [], "num_players": 2, "num_simulations": 1000}`)))
+	var resp WinProbabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("single: status %d err %v", rec.Code, err)
+	}
+	if resp.Method != MethodTable || resp.Simulations != preflop.Embedded().RandomSims() || math.Abs(resp.Stats.Equity.Value-0.852) > 0.005 {
+		t.Errorf("single: %+v %+v", resp, resp.Stats)
+	}
+	// No seed or RNG went into a table answer.
+	if resp.Seed != nil || resp.RNG != "" {
+		t.Errorf("single: seed %v, rng %q", resp.Seed, resp.RNG)
+	}
+
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"players": [{"range": "AA"}, {"range": "KK"}], "community_cards": [], "num_simulations": 1000}`)))
+	var multi WinProbabilityMultiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&multi); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("multi: status %d err %v", rec.Code, err)
+	}
+	if multi.Method != MethodTable || math.Abs(multi.Players[0].Equity+multi.Players[1].Equity-1) > 2e-4 || multi.Players[0].Equity < 0.8 ||
+		multi.Simulations != preflop.Embedded().HeadsUpSims() || multi.Seed != nil || multi.RNG != "" {
+		t.Errorf("multi: %+v", multi)
+	}
+
+	// A board, a partial class, "monte_carlo" or a target or deadline simulate.
+	for _, body := range []string{
+		`{"hole_cards": ["SA", "DA"], "num_players": 2, "target_margin": 0.05}`,
+		`{"hole_cards": ["SA", "DA"], "num_players": 2, "num_simulations": 100, "max_duration_ms": 1000}`,
+		`{"hole_cards": ["SA", "DA"], "community_cards": ["H2", "H3", "H4"], "num_players": 2, "num_simulations": 100}`,
+		`{"hole_cards": ["SA", "DA"], "num_players": 2, "num_simulations": 100, "method": "monte_carlo"}`,
+		`{"hole_cards": ["SA", "DA"], "num_players": 11, "num_simulations": 100}`,
+	} {
+		rec := httptest.NewRecorder()
+		HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		var resp WinProbabilityResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Method == MethodTable || resp.Seed == nil {
+			t.Errorf("%s: %+v %v", body, resp, err)
+		}
+	}
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"players": [{"range": "AA, KK"}, {"range": "KK"}], "community_cards": [], "num_simulations": 100}`)))
+	if err := json.NewDecoder(rec.Body).Decode(&multi); err != nil || multi.Method == MethodTable {
+		t.Errorf("two classes: %+v %v", multi, err)
+	}
+}
+
+func TestPreflopGrid(t *testing.T) {
+	get := func(query string) (int, PreflopGridResponse, ErrorResponse) {
+		rec := httptest.NewRecorder()
+		HandlePreflopGrid(rec, httptest.NewRequest("GET", "/api/preflop-grid"+query, nil))
+		var grid PreflopGridResponse
+		var errResp ErrorResponse
+		if rec.Code == http.StatusOK {
+			_ = json.NewDecoder(rec.Body).Decode(&grid)
+		} else {
+			_ = json.NewDecoder(rec.Body).Decode(&errResp)
+		}
+		return rec.Code, grid, errResp
+	}
+	code, grid, _ := get("")
+	if code != http.StatusOK || len(grid.Grid) != 13 || len(grid.Grid[12]) != 13 || grid.Opponents != 1 || strings.Join(grid.Ranks, "") != "AKQJT98765432" {
+		t.Fatalf("status %d %+v", code, grid)
+	}
+	for i, want := range []string{"AA", "AKs", "AKo", "22", "72o"} {
+		c := [][2]int{{0, 0}, {0, 1}, {1, 0}, {12, 12}, {12, 7}}[i]
+		if got := grid.Grid[c[0]][c[1]]; got.Hand != want || got.Equity <= 0 {
+			t.Errorf("cell %v: %+v, want %s", c, got, want)
+		}
+	}
+
+	code, grid, _ = get("?vs=AKs")
+	if aa := grid.Grid[0][0]; code != http.StatusOK || grid.Vs != "AKs" || aa.Equity < 0.85 || aa.Equity > 0.9 {
+		t.Errorf("vs AKs: status %d AA %+v", code, aa)
+	}
+	code, grid, _ = get("?opponents=5")
+	if code != http.StatusOK || grid.Opponents != 5 || grid.Grid[0][0].Equity > 0.6 {
+		t.Errorf("5 opponents: status %d AA %+v", code, grid.Grid[0][0])
+	}
+
+	code, _, errResp := get("?opponents=10&vs=AK")
+	if code != http.StatusUnprocessableEntity || len(errResp.Fields) != 3 {
+		t.Errorf("status %d %+v", code, errResp.Fields)
+	}
+}
+
+func TestRunExactErrors(t *testing.T) {
+	for _, tt := range []struct {
+		err    error
+		status int
+		code   string
+	}{
+		{context.DeadlineExceeded, http.StatusServiceUnavailable, CodeExactTimeout},
+		{context.Canceled, http.StatusServiceUnavailable, CodeExactCancelled},
+		{montecarlo.ErrInvalidInput, http.StatusInternalServerError, CodeExactFailed},
+	} {
+		f := func(context.Context) error { return tt.err }
+		// Without num_simulations every failure is answered.
+		rec := httptest.NewRecorder()
+		var resp ErrorResponse
+		if runExact(context.Background(), rec, 0, f) || rec.Code != tt.status {
+			t.Errorf("%v: status %d", tt.err, rec.Code)
+		} else if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Code != tt.code {
+			t.Errorf("%v: %+v, %v", tt.err, resp, err)
+		}
+		// With it the caller falls back to Monte Carlo and answers.
+		rec = httptest.NewRecorder()
+		if runExact(context.Background(), rec, 100, f) || rec.Body.Len() != 0 {
+			t.Errorf("%v with num_simulations: wrote %q", tt.err, rec.Body)
+		}
+	}
+	if !runExact(context.Background(), httptest.NewRecorder(), 0, func(context.Context) error { return nil }) {
+		t.Error("success: not reported")
+	}
+}
@@ -1,77 +1,309 @@
 package api
 
-// EvaluateRequest: 2 hole + 5 community cards.
+// EvaluateRequest: 2 hole (4 or 5 for Omaha) + 5 community cards.
+// Game selects the rules: "holdem" (default) or "omaha".
 type EvaluateRequest struct {
-	HoleCards     []string `json:"hole_cards"`
+	Game           string   `json:"game,omitempty"`
+	CardFormat     string   `json:"card_format,omitempty"` // "suit-first" (default), "rank-first" or "unicode"
+	Jokers         int      `json:"jokers,omitempty"`      // 0 to 2 jokers in the deck, all wild
+	WildRanks      []string `json:"wild_ranks,omitempty"`  // wild ranks, e.g. ["2"] for deuces wild
+	HoleCards      []string `json:"hole_cards"`
 	CommunityCards []string `json:"community_cards"`
 }
 
-// EvaluateResponse: best hand description and type.
+// EvaluateResponse: best hand description and type. The hand's class counts from 1
+// (royal flush) to 7462 (7-5-4-3-2) in Hold'em and Omaha and to 1404 (J-9-8-7-6) in
+// short deck, whose deck deals 376,992 five-card hands.
 type EvaluateResponse struct {
-	BestHand     []string `json:"best_hand"`      // 5 cards (best hand)
-	WinningCards []string `json:"winning_cards"` // subset that defines the hand (e.g. 2 for high card, 4 for two pair)
+	BestHand     []string `json:"best_hand"`             // 5 cards (best hand)
+	PlayedHand   []string `json:"played_hand,omitempty"` // wild cards only: best_hand with each wild replaced by the card it stands for, "?A" for a rank whose four cards are all in the hand
+	WinningCards []string `json:"winning_cards"`         // subset that defines the hand (e.g. 2 for high card, 4 for two pair)
 	HandType     string   `json:"hand_type"`
-	HandValue    string   `json:"hand_value"`    // same as hand_type for display
+	HandValue    string   `json:"hand_value"`         // same as hand_type for display
+	LowHand      []string `json:"low_hand,omitempty"` // hi/lo games: best qualifying low, if any
+	HandRank     int      `json:"hand_rank"`          // equivalence class under the game's ruleset, 1 (royal flush) to its class count (its worst high card); five of a kind -13 (aces) to -1 (deuces)
+	HandsBeating int      `json:"hands_beating"`      // number of distinct hands of the ruleset that beat this one; for five of a kind, the higher fives of a kind
+	Percentile   float64  `json:"percentile"`         // percent of all 5-card hands dealt from the ruleset's deck without wild cards this one beats (ties count half); 100 for five of a kind
 }
 
-// CompareRequest: two hands, each 2 hole + 5 community.
+// CompareRequest: two hands, each 2 hole (4 or 5 for Omaha) + 5 community.
 type CompareRequest struct {
-	Hand1 struct {
-		HoleCards     []string `json:"hole_cards"`
+	Game       string   `json:"game,omitempty"`
+	CardFormat string   `json:"card_format,omitempty"` // "suit-first" (default), "rank-first" or "unicode"
+	Jokers     int      `json:"jokers,omitempty"`
+	WildRanks  []string `json:"wild_ranks,omitempty"`
+	Hand1      struct {
+		HoleCards      []string `json:"hole_cards"`
 		CommunityCards []string `json:"community_cards"`
 	} `json:"hand1"`
 	Hand2 struct {
-		HoleCards     []string `json:"hole_cards"`
+		HoleCards      []string `json:"hole_cards"`
 		CommunityCards []string `json:"community_cards"`
 	} `json:"hand2"`
 }
 
 // CompareResponse: best hand for each and winner.
 type CompareResponse struct {
-	Hand1Best       []string `json:"hand1_best"`
-	Hand1WinningCards []string `json:"hand1_winning_cards"`
-	Hand1Type       string   `json:"hand1_type"`
-	Hand2Best       []string `json:"hand2_best"`
-	Hand2WinningCards []string `json:"hand2_winning_cards"`
-	Hand2Type       string   `json:"hand2_type"`
-	Winner          string   `json:"winner"` // "hand1", "hand2", "tie"
+	Hand1Best         []string           `json:"hand1_best"`
+	Hand1WinningCards []string           `json:"hand1_winning_cards"`
+	Hand1Type         string             `json:"hand1_type"`
+	Hand2Best         []string           `json:"hand2_best"`
+	Hand2WinningCards []string           `json:"hand2_winning_cards"`
+	Hand2Type         string             `json:"hand2_type"`
+	Winner            string             `json:"winner"` // "hand1", "hand2", "tie"
+	Hand1Description  string             `json:"hand1_description"`
+	Hand2Description  string             `json:"hand2_description"`
+	Explanation       CompareExplanation `json:"explanation"`
+	// Hi/lo games only: best qualifying lows and who wins the low half ("none" if nobody qualifies).
+	Hand1Low  []string `json:"hand1_low,omitempty"`
+	Hand2Low  []string `json:"hand2_low,omitempty"`
+	LowWinner string   `json:"low_winner,omitempty"`
+	// Wild cards only: the best hands with each wild replaced by the card it stands for
+	// (see EvaluateResponse.PlayedHand).
+	Hand1Played []string `json:"hand1_played,omitempty"`
+	Hand2Played []string `json:"hand2_played,omitempty"`
+}
+
+// CompareExplanation: the deciding factor of a comparison.
+// Step 0 is the hand type, step i >= 1 the i-th tiebreaker rank; -1 for a tie.
+type CompareExplanation struct {
+	Step       int      `json:"step"`
+	Kicker     bool     `json:"kicker"`
+	Hand1Cards []string `json:"hand1_cards"` // cards of hand1 that decided (e.g. the kicker)
+	Hand2Cards []string `json:"hand2_cards"`
+	Text       string   `json:"text"` // e.g. "both Two Pairs, Queens and Fives; hand1 wins on the Ace kicker"
 }
 
-// WinProbabilityRequest: 2 hole + 0/3/4/5 community + num_players + num_simulations.
+// WinProbabilityRequest: 2 hole (4 or 5 for Omaha) + 0/3/4/5 community + num_players + num_simulations.
+// Opponents get as many random hole cards as the player, or a hand from their range.
 type WinProbabilityRequest struct {
+	Game           string   `json:"game,omitempty"`
+	Jokers         int      `json:"jokers,omitempty"`     // 0 to 2 jokers in the deck, all wild
+	WildRanks      []string `json:"wild_ranks,omitempty"` // wild ranks, e.g. ["2"] for deuces wild
 	HoleCards      []string `json:"hole_cards"`
 	CommunityCards []string `json:"community_cards"`
+	DeadCards      []string `json:"dead_cards,omitempty"` // stud only: cards out of play, e.g. folded upcards
 	NumPlayers     int      `json:"num_players"`
-	NumSimulations int      `json:"num_simulations"`
+	OpponentRanges []string `json:"opponent_ranges,omitempty"` // Hold'em: a range per opponent, e.g. "22+, ATs+, KQo"; "" or none for a random hand
+	NumSimulations int      `json:"num_simulations"`           // optional when method is "exact"
+	Method         string   `json:"method,omitempty"`          // "auto" (default), "exact" or "monte_carlo"
+	ExactThreshold int64    `json:"exact_threshold,omitempty"` // most runouts "auto" enumerates; 0 for the server default
+	Seed           *uint64  `json:"seed,omitempty"`            // master seed; random when absent
+	RNG            string   `json:"rng,omitempty"`             // "pcg" (default), "chacha8" or "crypto"
+	TargetMargin   float64  `json:"target_margin,omitempty"`   // stop once every 95% CI half-width is at most this
+	MaxDurationMS  int      `json:"max_duration_ms,omitempty"` // stop after this long
+	HandTypes      bool     `json:"hand_types,omitempty"`      // report how often each hand type is made and wins
+	Sampling       string   `json:"sampling,omitempty"`        // "plain" (default), "stratified", "antithetic" or "importance"
 }
 
-// WinProbabilityResponse: win and tie probability 0.0 to 1.0.
+// WinProbabilityResponse: win and tie probability 0.0 to 1.0. Win is holding the best
+// hand alone, tie sharing it with any opponent, even while beating the others.
+// In hi/lo games win is the scoop probability, tie the probability of a split pot,
+// and HiLo has the half-pot shares.
 type WinProbabilityResponse struct {
-	WinProbability float64 `json:"win_probability"`
-	TieProbability float64 `json:"tie_probability"`
-	Description    string  `json:"description"`
+	WinProbability float64             `json:"win_probability"`
+	TieProbability float64             `json:"tie_probability"`
+	Description    string              `json:"description"`
+	HiLo           *HiLoShares         `json:"hi_lo,omitempty"`
+	Method         string              `json:"method"`                 // "exact", "monte_carlo" or "table"
+	Combinations   int64               `json:"combinations,omitempty"` // runouts enumerated when exact
+	Seed           *uint64             `json:"seed,omitempty"`         // the request's seed, or the one picked for it; none from a table
+	RNG            string              `json:"rng,omitempty"`          // none from a table
+	Stats          *EquityStats        `json:"stats,omitempty"`        // win, tie and equity with confidence intervals
+	Simulations    int                 `json:"simulations,omitempty"`  // simulations run (none when exact), or those behind a table
+	StopReason     string              `json:"stop_reason"`            // "completed", "target_margin", "max_duration" or "cancelled"
+	HandTypes      []HandTypeFrequency `json:"hand_types,omitempty"`
+
+	// Monte Carlo only: the sampling strategy, the plain simulations that would be as
+	// precise (simulations itself under plain sampling) and the largest variance of an
+	// equity estimate.
+	Sampling            string  `json:"sampling,omitempty"`
+	EffectiveSampleSize float64 `json:"effective_sample_size,omitempty"`
+	Variance            float64 `json:"variance,omitempty"`
+}
+
+// HandTypeFrequency: how often a player makes one hand type and what it wins.
+type HandTypeFrequency struct {
+	HandType string   `json:"hand_type"`
+	Turn     *float64 `json:"turn,omitempty"` // fraction holding it on the turn, when the board started on the flop
+	River    float64  `json:"river"`          // fraction finishing with it
+	Equity   float64  `json:"equity"`         // pot share won with it; these sum to the player's equity
+	WinRate  float64  `json:"win_rate"`       // average pot share won when finishing with it
+}
+
+// Estimate is a probability with its standard error and 95% confidence interval
+// (zero width when computed exactly).
+type Estimate struct {
+	Value  float64 `json:"value"`
+	StdErr float64 `json:"std_err"`
+	CILow  float64 `json:"ci95_low"`
+	CIHigh float64 `json:"ci95_high"`
+}
+
+// EquityStats: a player's win, tie and equity (average share of the pot) estimates.
+type EquityStats struct {
+	Win    Estimate `json:"win"`
+	Tie    Estimate `json:"tie"`
+	Equity Estimate `json:"equity"`
+}
+
+// HiLoShares: one player's average result in a hi/lo split-pot game.
+type HiLoShares struct {
+	Scoop    float64 `json:"scoop"`     // won the whole pot alone
+	HighHalf float64 `json:"high_half"` // average share of the high half
+	LowHalf  float64 `json:"low_half"`  // average share of the low half
+	Equity   float64 `json:"equity"`    // average fraction of the pot
 }
 
 // WinProbabilityMultiRequest: all players' hole cards + community + num_simulations.
-// One simulation run; returned win/tie per player sum to 100%.
+// One simulation run; returned equities sum to 100%.
 type WinProbabilityMultiRequest struct {
-	Players        []struct{ HoleCards []string `json:"hole_cards"` } `json:"players"`
+	Game      string   `json:"game,omitempty"`
+	Jokers    int      `json:"jokers,omitempty"`
+	WildRanks []string `json:"wild_ranks,omitempty"`
+	Players   []struct {
+		HoleCards []string `json:"hole_cards"`         // stud: the known down cards (0 to 3)
+		UpCards   []string `json:"up_cards,omitempty"` // stud only: the up cards (0 to 4)
+		Range     string   `json:"range,omitempty"`    // Hold'em: a weighted hand range instead of hole_cards
+	} `json:"players"`
+	DeadCards      []string `json:"dead_cards,omitempty"` // stud only: cards out of play, e.g. folded upcards
 	CommunityCards []string `json:"community_cards"`
-	NumSimulations int     `json:"num_simulations"`
+	NumSimulations int      `json:"num_simulations"`
+	Method         string   `json:"method,omitempty"`
+	ExactThreshold int64    `json:"exact_threshold,omitempty"`
+	Seed           *uint64  `json:"seed,omitempty"`
+	RNG            string   `json:"rng,omitempty"`
+	TargetMargin   float64  `json:"target_margin,omitempty"`
+	MaxDurationMS  int      `json:"max_duration_ms,omitempty"`
+	HandTypes      bool     `json:"hand_types,omitempty"`
+	Sampling       string   `json:"sampling,omitempty"` // "plain" (default), "stratified", "antithetic" or "importance"
+
+	// Stream only: send a progress event once progress_every simulations have run since
+	// the last one, or progress_interval_ms have passed (250 when neither is set). Both
+	// are checked every 32,768 simulations, so they bound the spacing from below.
+	ProgressEvery      int `json:"progress_every,omitempty"`
+	ProgressIntervalMS int `json:"progress_interval_ms,omitempty"`
 }
 
 // WinProbabilityMultiPlayer is one entry in WinProbabilityMultiResponse.
+// Tie counts the pots the player splits; equity is the average share of the pot won,
+// 1/k of each k-way split. Hi/lo games report scoop as win, split pots as tie, plus the
+// half-pot shares.
 type WinProbabilityMultiPlayer struct {
-	WinProbability float64 `json:"win_probability"`
-	TieProbability float64 `json:"tie_probability"`
+	WinProbability float64             `json:"win_probability"`
+	TieProbability float64             `json:"tie_probability"`
+	Equity         float64             `json:"equity"`
+	HiLo           *HiLoShares         `json:"hi_lo,omitempty"`
+	Stats          *EquityStats        `json:"stats,omitempty"`
+	HandTypes      []HandTypeFrequency `json:"hand_types,omitempty"`
+}
+
+// EquityProgress is a "progress" event of /api/win-probability-multi/stream: the
+// estimates after Simulations simulations. Margin is the widest 95% confidence
+// half-width so far, the one target_margin stops on.
+type EquityProgress struct {
+	Simulations int                         `json:"simulations"`
+	Margin      float64                     `json:"margin"`
+	Players     []WinProbabilityMultiPlayer `json:"players"`
 }
 
-// WinProbabilityMultiResponse: per-player win and tie (tie same for all); sum = 100%.
+// WinProbabilityMultiResponse: per-player win, tie and equity. The equities sum to 100%,
+// and so do the wins plus the pots that were split.
 type WinProbabilityMultiResponse struct {
-	Players []WinProbabilityMultiPlayer `json:"players"`
+	Players      []WinProbabilityMultiPlayer `json:"players"`
+	Method       string                      `json:"method"`
+	Combinations int64                       `json:"combinations,omitempty"`
+	Seed         *uint64                     `json:"seed,omitempty"` // as in WinProbabilityResponse
+	RNG          string                      `json:"rng,omitempty"`
+	Simulations  int                         `json:"simulations,omitempty"`
+	StopReason   string                      `json:"stop_reason"`
+
+	// Monte Carlo only, as in WinProbabilityResponse.
+	Sampling            string  `json:"sampling,omitempty"`
+	EffectiveSampleSize float64 `json:"effective_sample_size,omitempty"`
+	Variance            float64 `json:"variance,omitempty"`
 }
 
-// ErrorResponse for 4xx/5xx.
+// OutsRequest: two or more players' hole cards on a flop or turn.
+type OutsRequest struct {
+	Game       string   `json:"game,omitempty"`
+	CardFormat string   `json:"card_format,omitempty"` // "suit-first" (default), "rank-first" or "unicode"
+	Jokers     int      `json:"jokers,omitempty"`
+	WildRanks  []string `json:"wild_ranks,omitempty"`
+	Players    []struct {
+		HoleCards []string `json:"hole_cards"`
+	} `json:"players"`
+	CommunityCards []string `json:"community_cards"` // 3 (flop) or 4 (turn)
+}
+
+// OutsPlayer is one player's situation in OutsResponse. Outs are the next cards that put
+// the player alone ahead from behind or tied; tainted_outs are those that also improve an
+// opponent's hand type.
+type OutsPlayer struct {
+	HandType     string       `json:"hand_type"`
+	Ahead        bool         `json:"ahead"` // holds the best hand alone now
+	Outs         []string     `json:"outs"`
+	TaintedOuts  []string     `json:"tainted_outs"`
+	RunnerRunner []RunnerOuts `json:"runner_runner,omitempty"` // flop only: pairs that win although neither card is an out
+}
+
+// RunnerOuts is a turn and river pair and the hand type it makes.
+type RunnerOuts struct {
+	Cards    []string `json:"cards"`
+	HandType string   `json:"hand_type"`
+}
+
+// OutsCard is what one unseen card does for each player, in request order.
+type OutsCard struct {
+	Card    string           `json:"card"`
+	Players []OutsCardEffect `json:"players"`
+}
+
+// OutsCardEffect is one player's hand once the card is dealt.
+type OutsCardEffect struct {
+	HandType string `json:"hand_type"`
+	Improves bool   `json:"improves"` // a better hand type than before the card
+	Ahead    bool   `json:"ahead"`
+	Out      bool   `json:"out"`
+	Tainted  bool   `json:"tainted"`
+}
+
+// OutsResponse: each player's outs, and every unseen card with its effect on each player.
+type OutsResponse struct {
+	Players []OutsPlayer `json:"players"`
+	Cards   []OutsCard   `json:"cards"`
+}
+
+// PreflopGridResponse: every starting hand's Hold'em equity on the 13x13 grid, rows and
+// columns ranked from the ace down: pairs on the diagonal, suited hands above it (row
+// rank high) and offsuit hands below (column rank high). From the precomputed tables,
+// against opponents random hands or heads-up against the class vs.
+type PreflopGridResponse struct {
+	Ranks       []string        `json:"ranks"` // "A" to "2"
+	Opponents   int             `json:"opponents"`
+	Vs          string          `json:"vs,omitempty"`
+	Simulations int             `json:"simulations"` // behind each cell
+	Grid        [][]PreflopCell `json:"grid"`
+}
+
+// PreflopCell is one starting hand of PreflopGridResponse.
+type PreflopCell struct {
+	Hand   string  `json:"hand"` // e.g. "AKs", "TT", "72o"
+	Win    float64 `json:"win"`
+	Tie    float64 `json:"tie"`
+	Equity float64 `json:"equity"`
+}
+
+// ErrorResponse for 4xx/5xx. Validation errors (422) list every bad field in Fields.
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error  string       `json:"error"`
+	Code   string       `json:"code,omitempty"`   // see the Code constants
+	Fields []FieldError `json:"fields,omitempty"` // validation errors only
+}
+
+// FieldError: one bad field, by JSON path, e.g. "players[2].hole_cards[1]".
+type FieldError struct {
+	Path    string `json:"path"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"texashold-backend/hand"
+	"texashold-backend/montecarlo"
+	"texashold-backend/preflop"
+	"texashold-backend/ranges"
+)
+
+// preflopTable reports whether a request can be answered from the preflop tables:
+// "auto" Hold'em with no board, no wild cards, no hand types, plain sampling and no
+// target margin or deadline, which the tables' fixed runs could not honour.
+func preflopTable(method string, game hand.Variant, wilds hand.Wilds, comm []hand.Card, handTypes, adaptive bool, sampling montecarlo.Sampling) bool {
+	return method == MethodAuto && game == hand.Holdem && len(comm) == 0 && wilds.None() && !handTypes && !adaptive && sampling == montecarlo.PlainSampling
+}
+
+// preflopVsRandom looks up hole cards against opponents random hands, 1 to
+// preflop.MaxOpponents.
+func preflopVsRandom(hole []hand.Card, opponents int) montecarlo.Result {
+	t := preflop.Embedded()
+	p, _ := t.VsRandom(ranges.NewCombo(hole[0], hole[1]).Class(), opponents)
+	return montecarlo.Result{Players: []montecarlo.PlayerEquity{p}, Sims: t.RandomSims(), Stop: montecarlo.StopCompleted}
+}
+
+// preflopHeadsUp looks up two players whose ranges are each one whole class, and
+// reports false for any other players.
+func preflopHeadsUp(players []montecarlo.Player) (montecarlo.Result, bool) {
+	if len(players) != 2 || players[0].Range == nil || players[1].Range == nil {
+		return montecarlo.Result{}, false
+	}
+	a, okA := preflop.ClassOf(players[0].Range)
+	b, okB := preflop.ClassOf(players[1].Range)
+	if !okA || !okB {
+		return montecarlo.Result{}, false
+	}
+	t := preflop.Embedded()
+	return montecarlo.Result{
+		Players: []montecarlo.PlayerEquity{t.HeadsUp(a, b), t.HeadsUp(b, a)},
+		Sims:    t.HeadsUpSims(),
+		Stop:    montecarlo.StopCompleted,
+	}, true
+}
+
+// preflopGrid returns the 13x13 grid of the classes, ranks from the ace down: pairs on
+// the diagonal, suited hands above it and offsuit hands below, each cell filled by cell.
+func preflopGrid(cell func(c ranges.Class) PreflopCell) [][]PreflopCell {
+	grid := make([][]PreflopCell, 13)
+	for i := range grid {
+		grid[i] = make([]PreflopCell, 13)
+		for j := range grid[i] {
+			hi, lo := hand.RankA-i, hand.RankA-j
+			c := ranges.Class{High: max(hi, lo), Low: min(hi, lo), Suited: i < j}
+			grid[i][j] = cell(c)
+		}
+	}
+	return grid
+}
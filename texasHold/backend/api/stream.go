@@ -0,0 +1,64 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"texashold-backend/montecarlo"
+)
+
+// defaultProgressInterval spaces progress events when a request sets neither
+// progress_every nor progress_interval_ms.
+const defaultProgressInterval = 250 * time.Millisecond
+
+// eventStream writes a response as Server-Sent Events. The headers go out with the
+// first event, so a request that fails before then still gets a JSON error.
+type eventStream struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// send writes one event with v as its JSON data and flushes it to the client. A client
+// that has gone away is not an error: its request context stops the simulation.
+func (s *eventStream) send(event string, v interface{}) {
+	if !s.started {
+		s.w.Header().Set("Content-Type", "text/event-stream")
+		s.w.Header().Set("Cache-Control", "no-cache")
+		s.w.WriteHeader(http.StatusOK)
+		s.started = true
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("eventStream: %v", err)
+		return
+	}
+	if _, err := fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return
+	}
+	_ = http.NewResponseController(s.w).Flush()
+}
+
+// progress returns a Simulator.Progress that sends event(res) as a "progress" event
+// once every simulations have run since the last one or intervalMS have passed; 0
+// turns either off, and with both off events are defaultProgressInterval apart. The
+// simulator reports every round of simulations, and the check runs only then: both
+// limits are lower bounds on the spacing, and a game slow enough that a round takes
+// longer than intervalMS sends one event per round.
+func (s *eventStream) progress(every, intervalMS int, event func(res montecarlo.Result) interface{}) func(montecarlo.Result) {
+	interval := time.Duration(intervalMS) * time.Millisecond
+	if every == 0 && interval == 0 {
+		interval = defaultProgressInterval
+	}
+	lastSims, lastAt := 0, time.Now()
+	return func(res montecarlo.Result) {
+		due := every > 0 && res.Sims-lastSims >= every || interval > 0 && time.Since(lastAt) >= interval
+		if !due {
+			return
+		}
+		lastSims, lastAt = res.Sims, time.Now()
+		s.send("progress", event(res))
+	}
+}
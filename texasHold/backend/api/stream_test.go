@@ -0,0 +1,181 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type event struct {
+	name string
+	data string
+}
+
+// readEvent reads the next Server-Sent Event from sc, false at the end of the stream.
+func readEvent(sc *bufio.Scanner) (event, bool) {
+	var e event
+	for sc.Scan() {
+		line := sc.Text()
+		switch {
+		case line == "" && e.name != "":
+			return e, true
+		case strings.HasPrefix(line, "event: "):
+			e.name = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+	return e, false
+}
+
+func TestStreamEvents(t *testing.T) {
+	const body = `{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}, {"range": "QQ+"}], "community_cards": [], "num_simulations": 200000, "seed": 7, "method": "monte_carlo"`
+	rec := httptest.NewRecorder()
+	HandleWinProbabilityMultiStream(rec, httptest.NewRequest("POST", "/", strings.NewReader(body+`, "progress_every": 1}`)))
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("status %d, content type %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// Every round of the simulator but the last is reported, then the result.
+	sc := bufio.NewScanner(rec.Body)
+	var progress []EquityProgress
+	var result WinProbabilityMultiResponse
+	for e, ok := readEvent(sc); ok; e, ok = readEvent(sc) {
+		switch e.name {
+		case "progress":
+			var p EquityProgress
+			if err := json.Unmarshal([]byte(e.data), &p); err != nil {
+				t.Fatal(err)
+			}
+			progress = append(progress, p)
+		case "result":
+			if err := json.Unmarshal([]byte(e.data), &result); err != nil {
+				t.Fatal(err)
+			}
+		default:
+			t.Errorf("event %q", e.name)
+		}
+	}
+	if len(progress) != 6 {
+		t.Fatalf("%d progress events", len(progress))
+	}
+	for i, p := range progress {
+		if p.Simulations != (i+1)*32768 || len(p.Players) != 3 || p.Players[0].Stats == nil || p.Margin <= 0 {
+			t.Errorf("progress %d: %+v", i, p)
+		}
+		if i > 0 && p.Margin >= progress[i-1].Margin {
+			t.Errorf("progress %d: margin %v after %v", i, p.Margin, progress[i-1].Margin)
+		}
+	}
+
+	// The result is the one the JSON endpoint gives.
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(body+"}")))
+	var want WinProbabilityMultiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&want); err != nil {
+		t.Fatal(err)
+	}
+	if result.Simulations != 200000 || !reflect.DeepEqual(result, want) {
+		t.Errorf("result %+v, want %+v", result, want)
+	}
+
+	// Answers without a simulation are a lone result; bad requests a JSON error.
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMultiStream(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"]}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 100}`)))
+	if e, _ := readEvent(bufio.NewScanner(rec.Body)); e.name != "result" || !strings.Contains(e.data, `"method":"exact"`) {
+		t.Errorf("exact: %+v", e)
+	}
+	code, errResp := post(t, HandleWinProbabilityMultiStream, body+`, "progress_every": -1}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != "progress_every" {
+		t.Errorf("negative progress_every: status %d %+v", code, errResp.Fields)
+	}
+	code, errResp = post(t, HandleWinProbabilityMulti, body+`, "progress_interval_ms": 100}`)
+	if code != http.StatusUnprocessableEntity || errResp.Fields[0].Code != CodeUnsupported {
+		t.Errorf("progress without stream: status %d %+v", code, errResp.Fields)
+	}
+}
+
+// teeWriter copies what the handler writes, to see the events a gone client missed.
+type teeWriter struct {
+	http.ResponseWriter
+	buf *bytes.Buffer
+}
+
+func (w teeWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	return w.ResponseWriter.Write(p)
+}
+
+func (w teeWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func TestStreamDisconnect(t *testing.T) {
+	const omaha = `[{"hole_cards": ["HA", "HK", "SQ", "SJ"]}, {"hole_cards": ["S9", "D9", "C8", "D7"]}, {"hole_cards": ["HQ", "CJ", "C6", "D5"]}, {"hole_cards": ["ST", "DT", "C4", "H3"]}, {"hole_cards": ["DA", "CK", "H8", "S2"]}, {"hole_cards": ["SK", "D6", "C7", "HT"]}]`
+	for _, tt := range []struct {
+		name, body string
+		progress   bool // the stream sends progress before the result
+	}{
+		{"omaha", `{"game": "omaha", "players": ` + omaha + `, "community_cards": [], "num_simulations": 500000, "method": "monte_carlo", "progress_every": 1}`, true},
+		// Hi/lo and stud send only the result, but leaving still stops them.
+		{"omaha hi/lo", `{"game": "omaha-hilo", "players": ` + omaha + `, "community_cards": [], "num_simulations": 500000, "method": "monte_carlo"}`, false},
+		{"stud", `{"game": "stud", "players": [{"hole_cards": ["HA", "HK"]}, {}, {}, {}, {}, {}, {}], "community_cards": [], "num_simulations": 500000}`, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var written bytes.Buffer
+			started, done := make(chan struct{}), make(chan struct{})
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				close(started)
+				defer close(done)
+				HandleWinProbabilityMultiStream(teeWriter{w, &written}, r)
+			}))
+			defer srv.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req, _ := http.NewRequestWithContext(ctx, "POST", srv.URL, strings.NewReader(tt.body))
+			if tt.progress {
+				resp, err := http.DefaultClient.Do(req)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer resp.Body.Close()
+				if e, ok := readEvent(bufio.NewScanner(resp.Body)); !ok || e.name != "progress" {
+					t.Fatalf("first event %+v", e)
+				}
+			} else {
+				go func() {
+					if resp, err := http.DefaultClient.Do(req); err == nil {
+						resp.Body.Close()
+					}
+				}()
+				<-started
+				time.Sleep(20 * time.Millisecond)
+			}
+			cancel()
+			<-done
+
+			// The handler still wrote its result, of a simulation cut short.
+			sc := bufio.NewScanner(&written)
+			var last event
+			for e, ok := readEvent(sc); ok; e, ok = readEvent(sc) {
+				last = e
+			}
+			var result WinProbabilityMultiResponse
+			if err := json.Unmarshal([]byte(last.data), &result); err != nil || last.name != "result" {
+				t.Fatalf("last event %+v", last)
+			}
+			if result.StopReason != "cancelled" || result.Simulations >= 500000 {
+				t.Errorf("stopped after %d sims (%q)", result.Simulations, result.StopReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"texashold-backend/hand"
+	"texashold-backend/ranges"
+)
+
+// Error codes in ErrorResponse.Code and FieldError.Code.
+const (
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInvalidJSON      = "invalid_json"      // body is not JSON of the request's shape (400)
+	CodeValidation       = "validation_failed" // ErrorResponse.Code when Fields lists the problems (422)
+	CodeUnknownGame      = "unknown_game"      // game is not a known variant
+	CodeUnknownFormat    = "unknown_card_format"
+	CodeInvalidCard      = "invalid_card"      // a card string does not parse
+	CodeDuplicateCard    = "duplicate_card"    // a card appears twice across players and the board
+	CodeCardNotInDeck    = "card_not_in_deck"  // e.g. a 2 to 5 in short deck
+	CodeCardCount        = "wrong_card_count"  // too many or too few cards in a list
+	CodeOutOfRange       = "out_of_range"      // a number outside its allowed range
+	CodeTooManyPlayers   = "too_many_players"  // the players and the board need more cards than the deck has
+	CodeInvalidWilds     = "invalid_wilds"     // bad jokers or wild_ranks, or wild cards in a game without them
+	CodeUnsupported      = "unsupported"       // a field the game does not use, e.g. dead_cards outside stud
+	CodeInvalidRange     = "invalid_range"     // a hand range does not parse
+	CodeEmptyRange       = "empty_range"       // the known cards block every combo of a range
+	CodeImpossibleRanges = "impossible_ranges" // the ranges cannot all be dealt at once
+)
+
+// validator collects the problems of one request, each with the JSON path of the
+// offending field (e.g. "players[2].hole_cards[1]"), so a client can point at it.
+type validator struct {
+	game  hand.Variant
+	wilds hand.Wilds
+	errs  []FieldError
+	seen  map[hand.Card]string // path of each card parsed so far
+}
+
+func newValidator() *validator {
+	return &validator{seen: make(map[hand.Card]string)}
+}
+
+func (v *validator) add(path, code, format string, args ...interface{}) {
+	v.errs = append(v.errs, FieldError{Path: path, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// ok reports whether no problem has been found.
+func (v *validator) ok() bool {
+	return len(v.errs) == 0
+}
+
+// variant parses the game at path; later card checks use its deck.
+func (v *validator) variant(path, s string) hand.Variant {
+	game, err := hand.ParseVariant(s)
+	if err != nil {
+		v.add(path, CodeUnknownGame, "%v", err)
+	}
+	v.game = game
+	return game
+}
+
+// cardFormat parses the output card format at path.
+func (v *validator) cardFormat(path, s string) hand.CardFormat {
+	f, err := hand.ParseCardFormat(s)
+	if err != nil {
+		v.add(path, CodeUnknownFormat, "%v", err)
+	}
+	return f
+}
+
+// wildCards parses the wild card options; later card checks allow the jokers.
+// Hi/lo games have no wild cards.
+func (v *validator) wildCards(jokers int, ranks []string) hand.Wilds {
+	if jokers < 0 || jokers > 2 {
+		v.add("jokers", CodeInvalidWilds, "jokers must be 0 to 2, got %d", jokers)
+	} else {
+		v.wilds.Jokers = jokers
+	}
+	mask, err := hand.ParseWildRanks(ranks)
+	if err != nil {
+		v.add("wild_ranks", CodeInvalidWilds, "%v", err)
+	}
+	v.wilds.Ranks = mask
+	if !v.wilds.None() && (v.game.HiLo() || v.game.Stud()) {
+		v.add("game", CodeInvalidWilds, "%s is not played with wild cards", v.game)
+	}
+	return v.wilds
+}
+
+// deck returns the cards of the game's deck, jokers included.
+func (v *validator) deck() hand.CardSet {
+	deck := v.game.Rules().Cards()
+	for i := 1; i <= v.wilds.Jokers; i++ {
+		deck = deck.Add(hand.Joker(i))
+	}
+	return deck
+}
+
+// cards parses the card list at path. Blank entries are skipped. Each card must be in
+// the game's deck and must not repeat a card parsed earlier in the request.
+func (v *validator) cards(path string, ss []string) []hand.Card {
+	deck := v.deck()
+	var out []hand.Card
+	for i, s := range ss {
+		s = trimSpace(s)
+		if s == "" {
+			continue
+		}
+		p := fmt.Sprintf("%s[%d]", path, i)
+		c, err := hand.ParseCard(s)
+		switch {
+		case err != nil:
+			v.add(p, CodeInvalidCard, "invalid card %q", s)
+			continue
+		case !deck.Contains(c):
+			v.add(p, CodeCardNotInDeck, "card %s is not in the %s deck", c, v.game)
+		}
+		if first, dup := v.seen[c]; dup {
+			v.add(p, CodeDuplicateCard, "card %s is also at %s", c, first)
+		} else {
+			v.seen[c] = p
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+// holeCards parses a player's hole cards and checks their number for the game.
+func (v *validator) holeCards(path string, ss []string) []hand.Card {
+	hole := v.cards(path, ss)
+	if !v.game.ValidHoleCount(len(hole)) {
+		v.add(path, CodeCardCount, "need %s, got %d", holeCountText(v.game), len(hole))
+	}
+	return hole
+}
+
+// countedCards parses a card list that must hold min to max cards.
+func (v *validator) countedCards(path string, ss []string, min, max int) []hand.Card {
+	cards := v.cards(path, ss)
+	if len(cards) < min || len(cards) > max {
+		v.add(path, CodeCardCount, "need %d to %d cards, got %d", min, max, len(cards))
+	}
+	return cards
+}
+
+// deadCards parses cards known to be out of play (folded stud upcards); only stud uses them.
+func (v *validator) deadCards(path string, ss []string) []hand.Card {
+	dead := v.cards(path, ss)
+	if len(dead) > 0 && !v.game.Stud() {
+		v.add(path, CodeUnsupported, "%s has no dead cards; they are only used in stud games", v.game)
+	}
+	return dead
+}
+
+// board parses community cards; counts lists the allowed numbers of cards.
+func (v *validator) board(path string, ss []string, counts ...int) []hand.Card {
+	board := v.cards(path, ss)
+	for _, n := range counts {
+		if len(board) == n {
+			return board
+		}
+	}
+	want := make([]string, len(counts))
+	for i, n := range counts {
+		want[i] = fmt.Sprint(n)
+	}
+	v.add(path, CodeCardCount, "need %s community cards, got %d", strings.Join(want, ", "), len(board))
+	return board
+}
+
+// seenCards returns a copy of the cards parsed so far.
+func (v *validator) seenCards() map[hand.Card]string {
+	m := make(map[hand.Card]string, len(v.seen))
+	for c, p := range v.seen {
+		m[c] = p
+	}
+	return m
+}
+
+// setSeen replaces the cards parsed so far, e.g. to check two alternative boards
+// against the same hole cards.
+func (v *validator) setSeen(m map[hand.Card]string) {
+	v.seen = m
+}
+
+// intRange checks that n at path is within [min, max].
+func (v *validator) intRange(path string, n, min, max int) {
+	if n < min || n > max {
+		v.add(path, CodeOutOfRange, "%s must be %d to %d, got %d", path, min, max, n)
+	}
+}
+
+// deckSize checks that the need cards numPlayers are dealt in all (hole cards and a full
+// board, or seven each in stud) fit the deck.
+func (v *validator) deckSize(path string, numPlayers, need int) {
+	if n := v.deck().Count(); need > n {
+		v.add(path, CodeTooManyPlayers, "%d players need %d cards, more than the %d-card deck", numPlayers, need, n)
+	}
+}
+
+// handRange parses the hand range at path, e.g. "22+, ATs+, KQo", and checks that it
+// keeps a combo once the cards parsed so far, and those outside the deck, are removed;
+// parse every card first. Ranges are of two hole cards, so only Hold'em games use them.
+func (v *validator) handRange(path, s string) *ranges.Range {
+	if min, max := v.game.HoleCards(); min != 2 || max != 2 {
+		v.add(path, CodeUnsupported, "%s has no hand ranges; they are only used in Hold'em games", v.game)
+		return nil
+	}
+	r, err := ranges.Parse(s)
+	switch {
+	case err != nil:
+		v.add(path, CodeInvalidRange, "%v", err)
+		return nil
+	case r.Len() == 0:
+		v.add(path, CodeInvalidRange, "range %q has no hands", s)
+		return nil
+	}
+	blocked := hand.FullCardSet &^ v.deck()
+	for c := range v.seen {
+		blocked = blocked.Add(c)
+	}
+	if r.Without(blocked).Len() == 0 {
+		v.add(path, CodeEmptyRange, "the known cards block every hand of the range")
+	}
+	return r
+}
+
+// boardCounts returns the allowed numbers of community cards: none in stud, otherwise
+// a full board at showdown or any street for simulations.
+func boardCounts(game hand.Variant, showdown bool) []int {
+	switch {
+	case game.Stud():
+		return []int{0}
+	case showdown:
+		return []int{5}
+	default:
+		return []int{0, 3, 4, 5}
+	}
+}
+
+// writeErrors responds 422 with every problem found; Error repeats the first one.
+func (v *validator) writeErrors(w http.ResponseWriter) {
+	first := v.errs[0]
+	writeJSON(w, http.StatusUnprocessableEntity, ErrorResponse{
+		Error:  first.Path + ": " + first.Message,
+		Code:   CodeValidation,
+		Fields: v.errs,
+	})
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func post(t *testing.T, h http.HandlerFunc, body string) (int, ErrorResponse) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+	var resp ErrorResponse
+	if rec.Code != http.StatusOK {
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode error response: %v", err)
+		}
+	}
+	return rec.Code, resp
+}
+
+func TestValidationErrors(t *testing.T) {
+	code, resp := post(t, HandleWinProbabilityMulti, `{
+		"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["SA", "SK"]}, {"hole_cards": ["DQ", "XX"]}],
+		"community_cards": ["C2", "HK", "D7"],
+		"num_simulations": 100
+	}`)
+	if code != http.StatusUnprocessableEntity || resp.Code != CodeValidation {
+		t.Fatalf("status %d code %q, want 422 %q", code, resp.Code, CodeValidation)
+	}
+	want := map[string]string{
+		"players[2].hole_cards[1]": CodeInvalidCard,
+		"players[2].hole_cards":    CodeCardCount,
+		"community_cards[1]":       CodeDuplicateCard,
+	}
+	if len(resp.Fields) != len(want) {
+		t.Errorf("fields = %+v, want %v", resp.Fields, want)
+	}
+	for _, f := range resp.Fields {
+		if want[f.Path] != f.Code {
+			t.Errorf("field %s: code %q, want %q (%s)", f.Path, f.Code, want[f.Path], f.Message)
+		}
+	}
+
+	code, resp = post(t, HandleEvaluate, `{"game": "short-deck", "hole_cards": ["HA", "H2"], "community_cards": ["HA", "SK", "DQ", "CJ", "ST"]}`)
+	if code != http.StatusUnprocessableEntity || len(resp.Fields) != 2 ||
+		resp.Fields[0].Code != CodeCardNotInDeck || resp.Fields[1].Path != "community_cards[0]" {
+		t.Errorf("short deck: status %d, fields %+v", code, resp.Fields)
+	}
+
+	code, resp = post(t, HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 1, "num_simulations": 0}`)
+	if code != http.StatusUnprocessableEntity || len(resp.Fields) != 2 || resp.Fields[0].Path != "num_players" || resp.Fields[1].Path != "num_simulations" {
+		t.Errorf("numbers: status %d, fields %+v", code, resp.Fields)
+	}
+
+	code, resp = post(t, HandleEvaluate, `{"hole_cards": `)
+	if code != http.StatusBadRequest || resp.Code != CodeInvalidJSON {
+		t.Errorf("bad JSON: status %d code %q", code, resp.Code)
+	}
+
+	// Both hands of a comparison may share the board.
+	code, _ = post(t, HandleCompare, `{
+		"hand1": {"hole_cards": ["HA", "HK"], "community_cards": ["C2", "C3", "D7", "S9", "HT"]},
+		"hand2": {"hole_cards": ["SA", "SK"], "community_cards": ["C2", "C3", "D7", "S9", "HT"]}
+	}`)
+	if code != http.StatusOK {
+		t.Errorf("compare with a shared board: status %d", code)
+	}
+}
+
+func TestStudRequests(t *testing.T) {
+	code, resp := post(t, HandleWinProbabilityMulti, `{
+		"game": "razz",
+		"players": [{"hole_cards": ["HA", "H2"], "up_cards": ["D3"]}, {"up_cards": ["SK"]}],
+		"dead_cards": ["C4", "S9"],
+		"num_simulations": 1000
+	}`)
+	if code != http.StatusOK {
+		t.Errorf("razz multi: status %d %+v", code, resp)
+	}
+	code, resp = post(t, HandleWinProbabilityMulti, `{
+		"game": "stud",
+		"players": [{"hole_cards": ["HA", "H2"], "up_cards": ["D3"]}, {"up_cards": ["D3"]}],
+		"community_cards": ["C4", "C5", "C6"],
+		"num_simulations": 1000
+	}`)
+	want := map[string]string{"players[1].up_cards[0]": CodeDuplicateCard, "community_cards": CodeCardCount}
+	if code != http.StatusUnprocessableEntity || len(resp.Fields) != len(want) {
+		t.Fatalf("stud errors: status %d %+v", code, resp.Fields)
+	}
+	for _, f := range resp.Fields {
+		if want[f.Path] != f.Code {
+			t.Errorf("field %s: code %q, want %q", f.Path, f.Code, want[f.Path])
+		}
+	}
+	code, resp = post(t, HandleWinProbability, `{"hole_cards": ["HA", "HK"], "dead_cards": ["C2"], "num_players": 2, "num_simulations": 10}`)
+	if code != http.StatusUnprocessableEntity || resp.Fields[0].Code != CodeUnsupported {
+		t.Errorf("dead cards in hold'em: status %d %+v", code, resp.Fields)
+	}
+}
+
+func TestHandRankRequests(t *testing.T) {
+	// Ace-king-queen-jack-eight high; every ruleset numbers its own classes.
+	const highCard = `"hole_cards": ["HA", "SK"], "community_cards": ["DQ", "CJ", "H8", "S7", "D6"]`
+	for _, tt := range []struct {
+		body string
+		rank int
+	}{
+		{`{` + highCard + `}`, 6187},
+		{`{"game": "short-deck", ` + highCard + `}`, 1286},
+		{`{"game": "short-deck", "hole_cards": ["HA", "HK"], "community_cards": ["HQ", "HJ", "HT", "S7", "D6"]}`, 1},
+	} {
+		rec := httptest.NewRecorder()
+		HandleEvaluate(rec, httptest.NewRequest("POST", "/", strings.NewReader(tt.body)))
+		var resp EvaluateResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d err %v", tt.body, rec.Code, err)
+		}
+		if resp.HandRank != tt.rank || resp.HandsBeating != tt.rank-1 {
+			t.Errorf("%s: rank %d beaten by %d, want rank %d", tt.body, resp.HandRank, resp.HandsBeating, tt.rank)
+		}
+	}
+}
+
+func TestFiveOfAKindRequest(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleEvaluate(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"jokers": 1, "hole_cards": ["HA", "JK"], "community_cards": ["SA", "DA", "CA", "H7", "S2"]}`)))
+	var resp EvaluateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d err %v", rec.Code, err)
+	}
+	played := strings.Join(resp.PlayedHand, " ")
+	if resp.HandType != "Five of a Kind" || resp.HandRank != -13 || resp.HandsBeating != 0 || resp.Percentile != 100 ||
+		!strings.Contains(played, "?A") || strings.Count(played, "CA") != 1 {
+		t.Errorf("five aces: %+v", resp)
+	}
+}
+
+func TestRangeRequests(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleWinProbability(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"hole_cards": ["HA", "HK"], "community_cards": [], "num_players": 3, "opponent_ranges": ["22+, ATs+, KQo"], "num_simulations": 2000, "seed": 1}`)))
+	var resp WinProbabilityResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("opponent_ranges: status %d err %v", rec.Code, err)
+	}
+	if resp.Method != MethodMonteCarlo || resp.Simulations != 2000 || resp.Stats == nil {
+		t.Errorf("opponent_ranges: %+v", resp)
+	}
+
+	rec = httptest.NewRecorder()
+	HandleWinProbabilityMulti(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"players": [{"hole_cards": ["HA", "HK"]}, {"range": "QQ+"}, {"range": "AKs, 99"}], "community_cards": ["H9", "H5", "C2"], "num_simulations": 2000}`)))
+	var multi WinProbabilityMultiResponse
+	if err := json.NewDecoder(rec.Body).Decode(&multi); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("multi ranges: status %d err %v", rec.Code, err)
+	}
+	equity := 0.0
+	for _, p := range multi.Players {
+		equity += p.Stats.Equity.Value
+	}
+	if multi.Method != MethodMonteCarlo || equity < 0.999 || equity > 1.001 {
+		t.Errorf("multi ranges: %+v", multi)
+	}
+
+	for _, tt := range []struct {
+		handler http.HandlerFunc
+		body    string
+		path    string
+		code    string
+	}{
+		{HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2, "opponent_ranges": ["AX+"], "num_simulations": 10}`, "opponent_ranges[0]", CodeInvalidRange},
+		{HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2, "opponent_ranges": ["AA", "KK"], "num_simulations": 10}`, "opponent_ranges", CodeCardCount},
+		{HandleWinProbability, `{"hole_cards": ["HA", "SA"], "community_cards": ["DA", "D2", "D3"], "num_players": 2, "opponent_ranges": ["AA"], "num_simulations": 10}`, "opponent_ranges[0]", CodeEmptyRange},
+		{HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 2, "opponent_ranges": ["AA"], "method": "exact"}`, "method", CodeUnsupported},
+		{HandleWinProbability, `{"game": "omaha", "hole_cards": ["HA", "HK", "SA", "SK"], "num_players": 2, "opponent_ranges": ["AA"], "num_simulations": 10}`, "opponent_ranges[0]", CodeUnsupported},
+		{HandleWinProbability, `{"hole_cards": ["HA", "HK"], "num_players": 4, "opponent_ranges": ["AA", "AA", "AA"], "num_simulations": 10}`, "opponent_ranges", CodeImpossibleRanges},
+		{HandleWinProbabilityMulti, `{"players": [{"hole_cards": ["HA", "HK"]}, {"hole_cards": ["S9", "D9"], "range": "AA"}], "community_cards": [], "num_simulations": 10}`, "players[1].range", CodeInvalidRange},
+		{HandleWinProbabilityMulti, `{"players": [{"range": "AA"}, {"range": "AA"}, {"range": "AA"}], "community_cards": [], "num_simulations": 10}`, "players", CodeImpossibleRanges},
+	} {
+		code, errResp := post(t, tt.handler, tt.body)
+		if code != http.StatusUnprocessableEntity || errResp.Fields[0].Path != tt.path || errResp.Fields[0].Code != tt.code {
+			t.Errorf("%s: status %d %+v, want %s %s", tt.body, code, errResp.Fields, tt.path, tt.code)
+		}
+	}
+}
+
+func TestOutsRequests(t *testing.T) {
+	rec := httptest.NewRecorder()
+	HandleOuts(rec, httptest.NewRequest("POST", "/", strings.NewReader(
+		`{"card_format": "rank-first", "players": [{"hole_cards": ["HJ", "HT"]}, {"hole_cards": ["SA", "DA"]}], "community_cards": ["HA", "C7", "D2"]}`)))
+	var resp OutsResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("status %d err %v", rec.Code, err)
+	}
+	if len(resp.Cards) != 52-4-3 || len(resp.Cards[0].Players) != 2 || resp.Cards[0].Card != "2h" {
+		t.Errorf("cards: %d, first %+v", len(resp.Cards), resp.Cards[0])
+	}
+	if p := resp.Players[1]; p.HandType != "Three of a Kind" || !p.Ahead || p.Outs == nil || len(p.Outs) != 0 {
+		t.Errorf("set: %+v", p)
+	}
+	if rr := resp.Players[0].RunnerRunner; len(rr) == 0 || len(rr[0].Cards) != 2 {
+		t.Errorf("runner-runner: %+v", rr)
+	}
+
+	code, errResp := post(t, HandleOuts, `{"game": "omaha-hilo", "players": [{"hole_cards": ["HA", "H2", "S3", "S4"]}], "community_cards": ["C5", "C6", "C7", "C8", "C9"]}`)
+	want := []string{"game", "players", "community_cards"}
+	if code != http.StatusUnprocessableEntity || len(errResp.Fields) != len(want) {
+		t.Fatalf("status %d %+v", code, errResp.Fields)
+	}
+	for i, f := range errResp.Fields {
+		if f.Path != want[i] {
+			t.Errorf("field %d: %+v, want %s", i, f, want[i])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Command preflopgen builds the preflop equity tables that package preflop embeds:
+//
+//	go run ./cmd/preflopgen -out preflop/tables.bin
+//
+// The defaults take about an hour on one core; the simulations use every core.
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"texashold-backend/preflop"
+)
+
+func main() {
+	out := flag.String("out", "preflop/tables.bin", "file to write")
+	randomSims := flag.Int("random-sims", 1_000_000, "simulations per class and number of random opponents")
+	headsUpSims := flag.Int("heads-up-sims", 100_000, "simulations per pair of classes heads-up")
+	seed := flag.Uint64("seed", 1, "master seed")
+	flag.Parse()
+	if *randomSims <= 0 || *headsUpSims <= 0 {
+		log.Fatal("simulation counts must be positive")
+	}
+
+	lastPct := -1
+	t, err := preflop.Generate(context.Background(), *seed, *randomSims, *headsUpSims, func(done, total int) {
+		if pct := 100 * done / total; pct != lastPct {
+			lastPct = pct
+			log.Printf("%d%% (%d of %d entries)", pct, done, total)
+		}
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := t.Write(&buf); err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(*out, buf.Bytes(), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s (%d bytes)", *out, buf.Len())
+}
@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 // Suit and Rank for a card. Card = 2 chars: suit + rank (e.g. HA, S7, CT).
+// ParseCard also reads rank-first and unicode notations; see Card.Format for output.
 const (
 	SuitHeart   = 'H'
 	SuitSpade   = 'S'
 	SuitDiamond = 'D'
 	SuitClub    = 'C'
+	SuitJoker   = '*' // jokers have no suit; see Joker
+	SuitAny     = '?' // a wild card played as a rank alone; see StandIn
 )
 
 // Rank values: 2=0 .. K=11, A=12 (Ace high). For straights, A can be low (wheel).
@@ -37,8 +41,34 @@ type Card struct {
 	Rank int  // 0-12
 }
 
-// String returns 2-char representation e.g. "HA", "S7".
+// Joker returns joker n (1 or 2); a deck holds at most two. Jokers are wild
+// (see Wilds) and are written "JK" and "JK2".
+func Joker(n int) Card {
+	return Card{Suit: SuitJoker, Rank: n - 1}
+}
+
+// StandIn returns the card a wild card plays as when it stands for rank r and every
+// card of that rank is already in the hand, as in five of a kind. It has no suit and
+// is written "?K" ("K?" rank first).
+func StandIn(r int) Card {
+	return Card{Suit: SuitAny, Rank: r}
+}
+
+// IsStandIn reports whether c is a wild card standing for its rank alone (see StandIn).
+func (c Card) IsStandIn() bool {
+	return c.Suit == SuitAny
+}
+
+// IsJoker reports whether c is a joker.
+func (c Card) IsJoker() bool {
+	return c.Suit == SuitJoker
+}
+
+// String returns 2-char representation e.g. "HA", "S7"; "JK" or "JK2" for a joker.
 func (c Card) String() string {
+	if c.IsJoker() {
+		return jokerString(c)
+	}
 	suit := string(c.Suit)
 	rank := rankToChar(c.Rank)
 	return suit + rank
@@ -63,71 +93,165 @@ func rankToChar(r int) string {
 	return fmt.Sprintf("%d", r+2)
 }
 
-// ParseCard parses a 2-char string (e.g. "HA", "S7") into a Card. Returns error if invalid.
+// ParseCard parses one card. Accepted notations (case-insensitive):
+//   - suit first: "HA", "S7", "CT", "H10"
+//   - rank first: "Ah", "7s", "Tc", "10h"
+//   - unicode suits in either position: "A♠", "10♥", "♦K" (also ♤♡♢♧)
+//   - jokers: "JK", "Joker", "🃏", with "2" appended for the second joker ("JK2")
+//
+// Returns error if invalid.
 func ParseCard(s string) (Card, error) {
 	s = strings.TrimSpace(s)
 	if len(s) < 2 {
 		return Card{}, fmt.Errorf("card too short: %q", s)
 	}
-	s = strings.ToUpper(s)
-	var suit rune
-	for _, r := range s[:1] {
-		suit = r
-		break
+	c, n, err := scanCard(s)
+	if err != nil {
+		return Card{}, err
+	}
+	if n != len(s) {
+		return Card{}, fmt.Errorf("invalid card: %q", s)
+	}
+	return c, nil
+}
+
+// ParseCards parses a list of cards in any notation ParseCard accepts. Cards may be separated
+// by spaces, commas or brackets ("HA S7 D2", "[Ah, Kd]") or written together ("AhKd").
+// Normalizes \xa0 to space.
+func ParseCards(s string) ([]Card, error) {
+	s = strings.ReplaceAll(s, "\u00a0", " ")
+	var cards []Card
+	for {
+		s = strings.TrimLeft(s, " \t\r\n,;[]()")
+		if s == "" {
+			return cards, nil
+		}
+		c, n, err := scanCard(s)
+		if err != nil {
+			return nil, err
+		}
+		cards = append(cards, c)
+		s = s[n:]
+	}
+}
+
+// scanCard reads one card from the start of s and returns it with the number of bytes read.
+func scanCard(s string) (Card, int, error) {
+	if c, n := scanJoker(s); n > 0 {
+		return c, n, nil
+	}
+	if suit, n := scanSuit(s); n > 0 {
+		rank, m, err := scanRank(s[n:])
+		if err != nil {
+			return Card{}, 0, err
+		}
+		return Card{Suit: suit, Rank: rank}, n + m, nil
+	}
+	rank, m, err := scanRank(s)
+	if err != nil {
+		return Card{}, 0, err
+	}
+	suit, n := scanSuit(s[m:])
+	if n == 0 {
+		return Card{}, 0, fmt.Errorf("invalid suit: %q", firstRune(s[m:]))
 	}
-	rankChar := s[1:2]
-	var rank int
-	switch strings.ToUpper(rankChar) {
-	case "2":
+	return Card{Suit: suit, Rank: rank}, m + n, nil
+}
+
+// scanJoker reads a joker from the start of s; n is 0 if there is none. A trailing
+// "1" or "2" picks the joker unless it starts the next card ("JK2H" is JK then 2H).
+func scanJoker(s string) (c Card, n int) {
+	for _, prefix := range []string{"JOKER", "JK", "🃏"} {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			n = len(prefix)
+			break
+		}
+	}
+	if n == 0 {
+		return Card{}, 0
+	}
+	c = Joker(1)
+	if n < len(s) && (s[n] == '1' || s[n] == '2') {
+		if _, next := scanSuit(s[n+1:]); next == 0 {
+			c = Joker(int(s[n] - '0'))
+			n++
+		}
+	}
+	return c, n
+}
+
+func jokerString(c Card) string {
+	if c.Rank == 1 {
+		return "JK2"
+	}
+	return "JK"
+}
+
+// scanSuit reads a suit letter or symbol from the start of s; n is 0 if there is none.
+func scanSuit(s string) (suit rune, n int) {
+	r, size := utf8.DecodeRuneInString(s)
+	switch unicode.ToUpper(r) {
+	case SuitHeart, '♥', '♡':
+		suit = SuitHeart
+	case SuitSpade, '♠', '♤':
+		suit = SuitSpade
+	case SuitDiamond, '♦', '♢':
+		suit = SuitDiamond
+	case SuitClub, '♣', '♧':
+		suit = SuitClub
+	default:
+		return 0, 0
+	}
+	// Skip an emoji variation selector after a suit symbol.
+	if strings.HasPrefix(s[size:], "\ufe0f") {
+		size += len("\ufe0f")
+	}
+	return suit, size
+}
+
+// scanRank reads a rank ("2".."9", "10", "T", "J", "Q", "K", "A") from the start of s.
+func scanRank(s string) (rank int, n int, err error) {
+	if strings.HasPrefix(s, "10") {
+		return RankT, 2, nil
+	}
+	r, _ := utf8.DecodeRuneInString(s)
+	switch unicode.ToUpper(r) {
+	case '2':
 		rank = Rank2
-	case "3":
+	case '3':
 		rank = Rank3
-	case "4":
+	case '4':
 		rank = Rank4
-	case "5":
+	case '5':
 		rank = Rank5
-	case "6":
+	case '6':
 		rank = Rank6
-	case "7":
+	case '7':
 		rank = Rank7
-	case "8":
+	case '8':
 		rank = Rank8
-	case "9":
+	case '9':
 		rank = Rank9
-	case "T":
+	case 'T':
 		rank = RankT
-	case "J":
+	case 'J':
 		rank = RankJ
-	case "Q":
+	case 'Q':
 		rank = RankQ
-	case "K":
+	case 'K':
 		rank = RankK
-	case "A":
+	case 'A':
 		rank = RankA
 	default:
-		return Card{}, fmt.Errorf("invalid rank: %q", rankChar)
-	}
-	switch suit {
-	case SuitHeart, SuitSpade, SuitDiamond, SuitClub:
-		return Card{Suit: suit, Rank: rank}, nil
-	default:
-		return Card{}, fmt.Errorf("invalid suit: %q", string(suit))
+		return 0, 0, fmt.Errorf("invalid rank: %q", firstRune(s))
 	}
+	return rank, 1, nil
 }
 
-// ParseCards parses a space-separated string of cards (e.g. "HA S7 D2"). Normalizes \xa0 to space.
-func ParseCards(s string) ([]Card, error) {
-	s = strings.ReplaceAll(s, "\u00a0", " ")
-	parts := strings.Fields(s)
-	cards := make([]Card, 0, len(parts))
-	for _, p := range parts {
-		c, err := ParseCard(p)
-		if err != nil {
-			return nil, err
-		}
-		cards = append(cards, c)
-	}
-	return cards, nil
+// firstRune returns the first rune of s as a string ("" if s is empty).
+func firstRune(s string) string {
+	_, size := utf8.DecodeRuneInString(s)
+	return s[:size]
 }
 
 // normalizeSpace normalizes unicode spaces to ASCII space for parsing.
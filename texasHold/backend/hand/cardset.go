@@ -0,0 +1,140 @@
+package hand
+
+import (
+	"math/bits"
+	"math/rand/v2"
+)
+
+// Index returns the card's position 0-51: suit (H, S, D, C) * 13 + rank.
+// The jokers are 52 and 53.
+func (c Card) Index() int {
+	if c.IsJoker() {
+		return 52 + c.Rank
+	}
+	return suitIndex(c.Suit)*13 + c.Rank
+}
+
+// CardFromIndex is the inverse of Card.Index.
+func CardFromIndex(i int) Card {
+	if i >= 52 {
+		return Joker(i - 51)
+	}
+	return Card{Suit: indexSuits[i/13], Rank: i % 13}
+}
+
+var indexSuits = [4]rune{SuitHeart, SuitSpade, SuitDiamond, SuitClub}
+
+// CardSet is a set of cards stored as a bitmask over Card.Index.
+type CardSet uint64
+
+// NewCardSet returns the set of the given cards.
+func NewCardSet(cards ...Card) CardSet {
+	var s CardSet
+	for _, c := range cards {
+		s = s.Add(c)
+	}
+	return s
+}
+
+// FullCardSet is the set of all 52 cards.
+const FullCardSet CardSet = 1<<52 - 1
+
+// JokerSet is the set of both jokers.
+const JokerSet CardSet = 3 << 52
+
+// Add returns s with c added.
+func (s CardSet) Add(c Card) CardSet { return s | 1<<uint(c.Index()) }
+
+// Remove returns s without c.
+func (s CardSet) Remove(c Card) CardSet { return s &^ (1 << uint(c.Index())) }
+
+// Contains reports whether c is in s.
+func (s CardSet) Contains(c Card) bool { return s&(1<<uint(c.Index())) != 0 }
+
+// Union returns the cards in s or t.
+func (s CardSet) Union(t CardSet) CardSet { return s | t }
+
+// Intersect returns the cards in both s and t.
+func (s CardSet) Intersect(t CardSet) CardSet { return s & t }
+
+// Count returns the number of cards in s.
+func (s CardSet) Count() int { return bits.OnesCount64(uint64(s)) }
+
+// ForEach calls f for each card in s in index order.
+func (s CardSet) ForEach(f func(Card)) {
+	for m := uint64(s); m != 0; m &= m - 1 {
+		f(CardFromIndex(bits.TrailingZeros64(m)))
+	}
+}
+
+// Cards returns the cards in s in index order.
+func (s CardSet) Cards() []Card {
+	out := make([]Card, 0, s.Count())
+	s.ForEach(func(c Card) { out = append(out, c) })
+	return out
+}
+
+// Deck is a deck of the cards not in a dead set. Shuffle, then Deal and Burn from
+// the top; neither shuffling nor dealing allocates.
+type Deck struct {
+	cards     [54]Card
+	size      int        // live cards in the deck
+	next      int        // position of the next card to deal
+	shuffling bool       // Deal draws at random: a shuffle is in progress
+	r         *rand.Rand // source of the shuffle; nil for the global math/rand/v2 source
+}
+
+// NewDeck returns an unshuffled deck of all 52 cards except those in dead.
+func NewDeck(dead CardSet) *Deck {
+	return newDeckOf(FullCardSet &^ dead)
+}
+
+// newDeckOf returns an unshuffled deck of the cards in live (jokers included).
+func newDeckOf(live CardSet) *Deck {
+	d := &Deck{}
+	live.ForEach(func(c Card) {
+		d.cards[d.size] = c
+		d.size++
+	})
+	return d
+}
+
+// Shuffle returns all dealt cards to the deck and shuffles it with r
+// (the global math/rand/v2 source if r is nil). The shuffle is a Fisher-Yates shuffle
+// carried out by Deal one card at a time, so it only costs the cards dealt: a
+// simulation dealing 9 cards draws 9 random numbers, not one per card in the deck.
+func (d *Deck) Shuffle(r *rand.Rand) {
+	d.next = 0
+	d.shuffling = true
+	d.r = r
+}
+
+// Deal removes and returns the top card. It panics if the deck is empty.
+func (d *Deck) Deal() Card {
+	if d.next >= d.size {
+		panic("hand: deal from empty deck")
+	}
+	if d.shuffling {
+		// Swap a random card of the rest of the deck to the top.
+		var j int
+		if d.r != nil {
+			j = d.next + d.r.IntN(d.size-d.next)
+		} else {
+			j = d.next + rand.IntN(d.size-d.next)
+		}
+		d.cards[d.next], d.cards[j] = d.cards[j], d.cards[d.next]
+	}
+	c := d.cards[d.next]
+	d.next++
+	return c
+}
+
+// Burn discards the top card.
+func (d *Deck) Burn() {
+	d.Deal()
+}
+
+// Remaining returns the number of cards left to deal.
+func (d *Deck) Remaining() int {
+	return d.size - d.next
+}
@@ -0,0 +1,105 @@
+package hand
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCardIndexRoundTrip(t *testing.T) {
+	seen := make(map[int]bool)
+	for _, c := range allCards() {
+		i := c.Index()
+		if i < 0 || i > 51 || seen[i] {
+			t.Fatalf("%v: bad or repeated index %d", c, i)
+		}
+		seen[i] = true
+		if got := CardFromIndex(i); got != c {
+			t.Errorf("CardFromIndex(%d) = %v, want %v", i, got, c)
+		}
+	}
+}
+
+func TestCardSet(t *testing.T) {
+	cards, _ := ParseCards("HA S7 D2 CT")
+	s := NewCardSet(cards...)
+	if s.Count() != 4 {
+		t.Fatalf("Count = %d, want 4", s.Count())
+	}
+	for _, c := range cards {
+		if !s.Contains(c) {
+			t.Errorf("set should contain %v", c)
+		}
+	}
+	other, _ := ParseCards("HA C2")
+	o := NewCardSet(other...)
+	if got := s.Intersect(o); got.Count() != 1 || !got.Contains(cards[0]) {
+		t.Errorf("Intersect = %v", got.Cards())
+	}
+	if got := s.Union(o).Count(); got != 5 {
+		t.Errorf("Union count = %d, want 5", got)
+	}
+	if got := s.Remove(cards[0]); got.Contains(cards[0]) || got.Count() != 3 {
+		t.Errorf("Remove: %v", got.Cards())
+	}
+	if FullCardSet.Count() != 52 {
+		t.Errorf("FullCardSet count = %d", FullCardSet.Count())
+	}
+}
+
+func TestDeck(t *testing.T) {
+	dead, _ := ParseCards("HA SA")
+	d := NewDeck(NewCardSet(dead...))
+	if d.Remaining() != 50 {
+		t.Fatalf("Remaining = %d, want 50", d.Remaining())
+	}
+	d.Shuffle(rand.New(rand.NewPCG(1, 2)))
+	d.Burn()
+	var dealt CardSet
+	for d.Remaining() > 0 {
+		c := d.Deal()
+		if dealt.Contains(c) || c == dead[0] || c == dead[1] {
+			t.Fatalf("dealt %v twice or from dead cards", c)
+		}
+		dealt = dealt.Add(c)
+	}
+	if dealt.Count() != 49 {
+		t.Errorf("dealt %d cards, want 49", dealt.Count())
+	}
+	d.Shuffle(nil)
+	if d.Remaining() != 50 {
+		t.Errorf("Remaining after reshuffle = %d, want 50", d.Remaining())
+	}
+}
+
+func TestDeckShuffleUniform(t *testing.T) {
+	// Unshuffled decks deal in index order.
+	d := NewDeck(0)
+	for i := 0; i < 52; i++ {
+		if c := d.Deal(); c.Index() != i {
+			t.Fatalf("unshuffled card %d is %v", i, c)
+		}
+	}
+	// Every card is as likely in each of the first positions dealt, however many
+	// cards the previous shuffles dealt.
+	const n = 52 * 2000
+	r := rand.New(rand.NewPCG(3, 4))
+	var counts [3][52]int
+	for i := 0; i < n; i++ {
+		d.Shuffle(r)
+		for k := range counts {
+			counts[k][d.Deal().Index()]++
+		}
+		for j := i % 5; j > 0; j-- {
+			d.Burn()
+		}
+	}
+	mean, sd := float64(n)/52, math.Sqrt(float64(n)/52*(1-1.0/52))
+	for k := range counts {
+		for c, got := range counts[k] {
+			if math.Abs(float64(got)-mean) > 5*sd {
+				t.Errorf("card %d dealt %d times in position %d, want about %.0f", c, got, k, mean)
+			}
+		}
+	}
+}
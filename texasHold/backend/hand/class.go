@@ -0,0 +1,92 @@
+package hand
+
+import "math/bits"
+
+// Class returns the equivalence class of s: 1 for a royal flush up to NumStrengths
+// (7462) for 7-5-4-3-2, the usual numbering of the 7462 distinct 5-card hands.
+func (s Strength) Class() int {
+	return Standard.Class(s)
+}
+
+// BeatenBy returns the number of distinct hands (equivalence classes) that beat s.
+func (s Strength) BeatenBy() int {
+	return Standard.BeatenBy(s)
+}
+
+// Percentile returns the percentile rank of s among all 2,598,960 five-card hands:
+// the percentage of hands that are weaker, counting ties as half.
+func (s Strength) Percentile() float64 {
+	return Standard.Percentile(s)
+}
+
+// Class returns the equivalence class of a strength under rs: 1 is the royal flush and
+// rs.NumStrengths() the worst high card. Five of a kind, which needs wild cards, ranks
+// above them all: five aces are -13 and five deuces -1, so lower is still better.
+// Returns 0 for the invalid strength 0.
+func (rs *Ruleset) Class(s Strength) int {
+	if r := int(s) - len(rs.values); r >= 0 && r <= RankA {
+		return -(r + 1)
+	}
+	if s == 0 || int(s) > rs.NumStrengths() {
+		return 0
+	}
+	return rs.NumStrengths() + 1 - int(s)
+}
+
+// BeatenBy returns the number of distinct hands under rs that beat s; for five of a
+// kind, the higher fives of a kind.
+func (rs *Ruleset) BeatenBy(s Strength) int {
+	if r := int(s) - len(rs.values); r >= 0 && r <= RankA {
+		return RankA - r
+	}
+	if s == 0 || int(s) > rs.NumStrengths() {
+		return rs.NumStrengths()
+	}
+	return rs.NumStrengths() - int(s)
+}
+
+// Percentile returns the percentile rank of s among all 5-card hands dealt from rs's deck
+// without wild cards. Five of a kind beats every one of them and returns 100.
+func (rs *Ruleset) Percentile(s Strength) float64 {
+	if r := int(s) - len(rs.values); r >= 0 && r <= RankA {
+		return 100
+	}
+	if s == 0 || int(s) >= len(rs.below) {
+		return 0
+	}
+	total := float64(rs.below[len(rs.below)-1] + uint64(rs.freq[len(rs.freq)-1]))
+	return 100 * (float64(rs.below[s]) + 0.5*float64(rs.freq[s])) / total
+}
+
+// initFrequencies counts how many 5-card hands fall into each strength under rs.
+func (rs *Ruleset) initFrequencies() {
+	rs.freq = make([]uint32, len(rs.values))
+	rs.below = make([]uint64, len(rs.values))
+	chooseSuits := [5]uint32{1, 4, 6, 4, 1} // C(4, k)
+	forEachMultiset(5, func(counts *[13]uint8) {
+		s := rs.noFlush[0][quinaryIndex(counts, 5)]
+		if s == 0 {
+			return // ranks outside the deck
+		}
+		n := uint32(1)
+		distinct := 0
+		for _, c := range counts {
+			n *= chooseSuits[c]
+			if c > 0 {
+				distinct++
+			}
+		}
+		if distinct == 5 {
+			n -= 4 // the suited ones are flushes
+		}
+		rs.freq[s] += n
+	})
+	for m := 0; m < 1<<13; m++ {
+		if bits.OnesCount16(uint16(m)) == 5 && rs.flush[m] != 0 {
+			rs.freq[rs.flush[m]] += 4
+		}
+	}
+	for s := 2; s < len(rs.freq); s++ {
+		rs.below[s] = rs.below[s-1] + uint64(rs.freq[s-1])
+	}
+}
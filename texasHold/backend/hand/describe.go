@@ -0,0 +1,158 @@
+package hand
+
+import "fmt"
+
+var rankNames = [13]string{"Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten", "Jack", "Queen", "King", "Ace"}
+
+// RankName returns the English name of a rank, e.g. "Ace", "Seven".
+func RankName(r int) string {
+	if r < 0 || r >= len(rankNames) {
+		return "?"
+	}
+	return rankNames[r]
+}
+
+// RankPlural returns the plural name of a rank, e.g. "Aces", "Sixes".
+func RankPlural(r int) string {
+	if r == Rank6 {
+		return "Sixes"
+	}
+	return RankName(r) + "s"
+}
+
+// Describe returns a full description of the hand, e.g. "Full House, Kings full of Sevens",
+// "Ace-high flush" or "Two Pairs, Queens and Fives".
+func (v HandValue) Describe() string {
+	val := func(i int) int {
+		if i < len(v.Values) {
+			return v.Values[i]
+		}
+		return -1
+	}
+	switch v.Type {
+	case HighCard:
+		return RankName(val(0)) + "-high"
+	case OnePair:
+		return "Pair of " + RankPlural(val(0))
+	case TwoPairs:
+		return fmt.Sprintf("Two Pairs, %s and %s", RankPlural(val(0)), RankPlural(val(1)))
+	case ThreeOfAKind:
+		return "Three of a Kind, " + RankPlural(val(0))
+	case Straight:
+		return RankName(val(0)) + "-high straight"
+	case Flush:
+		return RankName(val(0)) + "-high flush"
+	case FullHouse:
+		return fmt.Sprintf("Full House, %s full of %s", RankPlural(val(0)), RankPlural(val(1)))
+	case FourOfAKind:
+		return "Four of a Kind, " + RankPlural(val(0))
+	case StraightFlush:
+		return RankName(val(0)) + "-high straight flush"
+	case RoyalFlush:
+		return "Royal Flush"
+	case FiveOfAKind:
+		return "Five of a Kind, " + RankPlural(val(0))
+	default:
+		return v.Type.String()
+	}
+}
+
+// kickerStart returns the index in HandValue.Values where the kickers of t begin
+// (len of Values if t has none). Earlier values make up the hand itself.
+func kickerStart(t HandType) int {
+	switch t {
+	case HighCard, OnePair, ThreeOfAKind, FourOfAKind, Flush:
+		return 1
+	case TwoPairs:
+		return 2
+	default:
+		return 5
+	}
+}
+
+// Explanation says why one hand beats another, or that they tie.
+type Explanation struct {
+	Winner int // 1 if the first hand wins, -1 if the second, 0 for a tie
+	// Step is the tie-break step that decided: 0 for the hand type, i >= 1 for the
+	// i-th tiebreaker value (HandValue.Values[i-1]); -1 for a tie.
+	Step   int
+	Kicker bool // the deciding value is a kicker rather than part of the made hand
+	// RankA and RankB are the ranks compared at Step (-1 for the hand-type step or a tie).
+	RankA, RankB int
+	// CardsA and CardsB are the cards of each best hand that decided: the cards with
+	// RankA/RankB, or the whole hand when the hand types differ.
+	CardsA, CardsB []Card
+	// DescriptionA and DescriptionB are the full descriptions of both hands.
+	DescriptionA, DescriptionB string
+}
+
+// Explain compares two best hands (as returned by BestHand) and names the deciding factor.
+func Explain(bestA []Card, valA HandValue, bestB []Card, valB HandValue) Explanation {
+	return Standard.Explain(bestA, valA, bestB, valB)
+}
+
+// Explain is Explain under rs's ranking of hand types.
+func (rs *Ruleset) Explain(bestA []Card, valA HandValue, bestB []Card, valB HandValue) Explanation {
+	e := Explanation{Step: -1, RankA: -1, RankB: -1, DescriptionA: valA.Describe(), DescriptionB: valB.Describe()}
+	if valA.Type != valB.Type {
+		e.Step = 0
+		e.Winner = rs.CompareHandValues(valA, valB)
+		e.CardsA = append([]Card(nil), bestA...)
+		e.CardsB = append([]Card(nil), bestB...)
+		return e
+	}
+	for i := 0; i < len(valA.Values) && i < len(valB.Values); i++ {
+		a, b := valA.Values[i], valB.Values[i]
+		if a == b {
+			continue
+		}
+		e.Winner = 1
+		if a < b {
+			e.Winner = -1
+		}
+		e.Step = i + 1
+		e.Kicker = i >= kickerStart(valA.Type)
+		e.RankA, e.RankB = a, b
+		e.CardsA = cardsOfRank(bestA, a, e.Kicker)
+		e.CardsB = cardsOfRank(bestB, b, e.Kicker)
+		return e
+	}
+	return e
+}
+
+// cardsOfRank returns the cards of rank r in best; only one of them for a kicker.
+func cardsOfRank(best []Card, r int, single bool) []Card {
+	var out []Card
+	for _, c := range best {
+		if c.Rank == r {
+			out = append(out, c)
+			if single {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Text renders the explanation with the given hand names, e.g.
+// "both Two Pairs, Queens and Fives; hand1 wins on the Ace kicker".
+func (e Explanation) Text(nameA, nameB string) string {
+	winner, loser := nameA, nameB
+	winDesc, loseDesc := e.DescriptionA, e.DescriptionB
+	winRank, loseRank := e.RankA, e.RankB
+	if e.Winner < 0 {
+		winner, loser = nameB, nameA
+		winDesc, loseDesc = e.DescriptionB, e.DescriptionA
+		winRank, loseRank = e.RankB, e.RankA
+	}
+	switch {
+	case e.Winner == 0:
+		return "tie: both have " + e.DescriptionA
+	case e.Step == 0:
+		return fmt.Sprintf("%s's %s beats %s's %s", winner, winDesc, loser, loseDesc)
+	case e.Kicker:
+		return fmt.Sprintf("both %s; %s wins on the %s kicker", e.DescriptionA, winner, RankName(winRank))
+	default:
+		return fmt.Sprintf("%s's %s beats %s's %s (%s over %s)", winner, winDesc, loser, loseDesc, RankName(winRank), RankName(loseRank))
+	}
+}
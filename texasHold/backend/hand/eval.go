@@ -6,6 +6,12 @@ import (
 
 // Evaluate5 returns the hand type and tiebreaker values for exactly 5 cards.
 func Evaluate5(cards []Card) HandValue {
+	return Standard.Evaluate5(cards)
+}
+
+// Evaluate5 returns the hand type and tiebreaker values for exactly 5 cards under rs,
+// whose lowest straight is A plus its four lowest ranks.
+func (rs *Ruleset) Evaluate5(cards []Card) HandValue {
 	if len(cards) != 5 {
 		return HandValue{Type: HighCard, Values: nil}
 	}
@@ -16,7 +22,7 @@ func Evaluate5(cards []Card) HandValue {
 	sort.Slice(c, func(i, j int) bool { return c[i].Rank > c[j].Rank })
 
 	isFlush := isFlush5(c)
-	isStraight, straightHigh := isStraight5(c)
+	isStraight, straightHigh := isStraight5(c, rs.MinRank)
 
 	if isFlush && isStraight {
 		if straightHigh == RankA {
@@ -100,7 +106,9 @@ func isFlush5(c []Card) bool {
 	return true
 }
 
-func isStraight5(c []Card) (bool, int) {
+// isStraight5 reports whether the 5 cards form a straight and its high card. With the
+// ace low the straight is A plus minRank..minRank+3 (A-2-3-4-5, or A-6-7-8-9 in short deck).
+func isStraight5(c []Card, minRank int) (bool, int) {
 	r := make([]int, 5)
 	for i := 0; i < 5; i++ {
 		r[i] = c[i].Rank
@@ -125,17 +133,17 @@ wheel:
 	if !hasA {
 		return false, 0
 	}
-	// Ranks must be A,5,4,3,2 -> 12,3,2,1,0
+	// Ranks must be A and the four lowest ranks, e.g. 12,3,2,1,0
 	seen := make(map[int]bool)
 	for i := 0; i < 5; i++ {
 		seen[r[i]] = true
 	}
-	for _, v := range []int{Rank5, Rank4, Rank3, Rank2} {
+	for v := minRank; v <= minRank+3; v++ {
 		if !seen[v] {
 			return false, 0
 		}
 	}
-	return true, Rank5 // wheel high card is 5
+	return true, minRank + 3 // wheel high card is 5 (9 in short deck)
 }
 
 func rankCounts(c []Card) map[int]int {
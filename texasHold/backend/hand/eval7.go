@@ -0,0 +1,296 @@
+package hand
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// NumStrengths is the number of distinct 5-card hand values (equivalence classes).
+const NumStrengths = 7462
+
+// Strength is a single comparable number for a poker hand: 1 is the weakest
+// high card (7-5-4-3-2), NumStrengths is a royal flush. Higher is better, and
+// equal strengths are exact ties. The order agrees with compareHandValues on Evaluate5.
+// Strengths from other rulesets (see Ruleset.Evaluate7) are numbered separately.
+type Strength uint16
+
+// Type returns the hand type of s.
+func (s Strength) Type() HandType {
+	return Standard.Type(s)
+}
+
+// Value returns the HandValue (type and tiebreakers) that s stands for.
+func (s Strength) Value() HandValue {
+	return Standard.Value(s)
+}
+
+// Value returns the HandValue of a strength returned by rs.Evaluate7 or rs.EvaluateWild.
+func (rs *Ruleset) Value(s Strength) HandValue {
+	if r := int(s) - len(rs.values); r >= 0 && r <= RankA {
+		return HandValue{Type: FiveOfAKind, Values: []int{r}}
+	}
+	if s == 0 || int(s) >= len(rs.values) {
+		return HandValue{Type: HighCard, Values: nil}
+	}
+	v := rs.values[s]
+	return HandValue{Type: v.Type, Values: append([]int(nil), v.Values...)}
+}
+
+// Type returns the hand type of a strength returned by rs.Evaluate7 or rs.EvaluateWild.
+// Unlike Value it does not allocate.
+func (rs *Ruleset) Type(s Strength) HandType {
+	if r := int(s) - len(rs.values); r >= 0 && r <= RankA {
+		return FiveOfAKind
+	}
+	if s == 0 || int(s) >= len(rs.values) {
+		return HighCard
+	}
+	return rs.values[s].Type
+}
+
+// NumStrengths returns the number of distinct hand values under rs.
+func (rs *Ruleset) NumStrengths() int {
+	return len(rs.values) - 1
+}
+
+// Evaluate7 returns the strength of the best 5-card hand within 5, 6 or 7 distinct
+// cards (2 hole + 0..5 community). It uses precomputed tables and does not allocate,
+// which makes it the evaluator for hot paths such as Monte Carlo simulations.
+// Returns 0 for fewer than 5 or more than 7 cards, and for a card with an unknown
+// suit or rank or a rank held more than four times.
+func Evaluate7(cards []Card) Strength {
+	return Standard.Evaluate7(cards)
+}
+
+// Evaluate7 is Evaluate7 under rs's ranking.
+func (rs *Ruleset) Evaluate7(cards []Card) Strength {
+	n := len(cards)
+	if n < 5 || n > 7 {
+		return 0
+	}
+	var counts [13]uint8
+	var masks [4]uint16
+	var suitCounts [4]uint8
+	for _, c := range cards {
+		s := suitIndex(c.Suit)
+		if s < 0 || c.Rank < Rank2 || c.Rank > RankA || counts[c.Rank] == 4 {
+			return 0
+		}
+		counts[c.Rank]++
+		masks[s] |= 1 << uint(c.Rank)
+		suitCounts[s]++
+	}
+	// With at most 7 cards a flush excludes quads and full houses, so it is the best hand
+	// (and short deck ranks it above a full house anyway).
+	for s := 0; s < 4; s++ {
+		if suitCounts[s] >= 5 {
+			if st := rs.flush[masks[s]]; st != 0 {
+				return st
+			}
+		}
+	}
+	return rs.noFlush[n-5][quinaryIndex(&counts, n)]
+}
+
+// suitIndex maps a suit rune to 0..3 (H, S, D, C), and any other rune to -1.
+func suitIndex(s rune) int {
+	switch s {
+	case SuitHeart:
+		return 0
+	case SuitSpade:
+		return 1
+	case SuitDiamond:
+		return 2
+	case SuitClub:
+		return 3
+	default:
+		return -1
+	}
+}
+
+var (
+	// quinaryOffsets[i][rem][c] is added to the index when rank i occurs c times
+	// and rem cards are still to be placed on ranks i..12.
+	quinaryOffsets [13][8][5]uint32
+	// quinarySizes[n] is the number of rank multisets of n cards.
+	quinarySizes [8]uint32
+)
+
+func init() {
+	initQuinary()
+	Standard.initTables()
+	ShortDeck.initTables()
+	if Standard.NumStrengths() != NumStrengths {
+		panic("hand: unexpected number of hand values")
+	}
+	initLowTables()
+}
+
+// initQuinary builds the offsets for a perfect hash of rank multisets (each rank 0..4 times).
+func initQuinary() {
+	// ways[n][m]: number of ways to put m cards on n ranks with at most 4 per rank.
+	var ways [14][8]uint32
+	ways[0][0] = 1
+	for n := 1; n <= 13; n++ {
+		for m := 0; m < 8; m++ {
+			for c := 0; c <= 4 && c <= m; c++ {
+				ways[n][m] += ways[n-1][m-c]
+			}
+		}
+	}
+	copy(quinarySizes[:], ways[13][:])
+	for i := 0; i < 13; i++ {
+		rest := 12 - i
+		for rem := 0; rem < 8; rem++ {
+			var off uint32
+			for c := 0; c <= 4; c++ {
+				quinaryOffsets[i][rem][c] = off
+				if c <= rem {
+					off += ways[rest][rem-c]
+				}
+			}
+		}
+	}
+}
+
+// quinaryIndex returns the index of the rank multiset counts (summing to n, each at
+// most 4) among all multisets of n cards.
+func quinaryIndex(counts *[13]uint8, n int) uint32 {
+	var idx uint32
+	rem := n
+	for i := 0; i < 13; i++ {
+		c := int(counts[i])
+		idx += quinaryOffsets[i][rem][c]
+		rem -= c
+	}
+	return idx
+}
+
+// forEachMultiset calls f for every rank multiset of n cards (at most 4 per rank).
+func forEachMultiset(n int, f func(counts *[13]uint8)) {
+	var counts [13]uint8
+	var rec func(i, rem int)
+	rec = func(i, rem int) {
+		if i == 13 {
+			if rem == 0 {
+				f(&counts)
+			}
+			return
+		}
+		for c := 0; c <= 4 && c <= rem; c++ {
+			counts[i] = uint8(c)
+			rec(i+1, rem-c)
+		}
+		counts[i] = 0
+	}
+	rec(0, n)
+}
+
+// initTables numbers all distinct 5-card hand values of rs by sorting the results of
+// Evaluate5, then derives the 6- and 7-card tables by taking the best 5-card subset.
+func (rs *Ruleset) initTables() {
+	suits := []rune{SuitHeart, SuitSpade, SuitDiamond, SuitClub}
+	var values []HandValue
+	var flushMasks []uint16
+	var flushVals []HandValue
+	multisetVals := make(map[uint32]HandValue)
+	lowMask := 1<<uint(rs.MinRank) - 1 // ranks not in the deck
+
+	// Non-flush hands: assign suits round-robin so equal ranks never share a suit
+	// and five distinct ranks never form a flush.
+	forEachMultiset(5, func(counts *[13]uint8) {
+		five := make([]Card, 0, 5)
+		for r := 0; r < 13; r++ {
+			for k := 0; k < int(counts[r]); k++ {
+				five = append(five, Card{Suit: suits[len(five)%4], Rank: r})
+			}
+		}
+		if five[0].Rank < rs.MinRank {
+			return
+		}
+		v := rs.Evaluate5(five)
+		multisetVals[quinaryIndex(counts, 5)] = v
+		values = append(values, v)
+	})
+	for m := uint16(0); m < 1<<13; m++ {
+		if bits.OnesCount16(m) != 5 || int(m)&lowMask != 0 {
+			continue
+		}
+		five := make([]Card, 0, 5)
+		for r := 0; r < 13; r++ {
+			if m&(1<<uint(r)) != 0 {
+				five = append(five, Card{Suit: SuitHeart, Rank: r})
+			}
+		}
+		v := rs.Evaluate5(five)
+		flushMasks = append(flushMasks, m)
+		flushVals = append(flushVals, v)
+		values = append(values, v)
+	}
+
+	sort.Slice(values, func(i, j int) bool { return rs.CompareHandValues(values[i], values[j]) < 0 })
+	strengthOf := make(map[string]Strength, len(values))
+	rs.values = []HandValue{{}}
+	for i, v := range values {
+		if i > 0 && rs.CompareHandValues(values[i-1], v) == 0 {
+			continue
+		}
+		rs.values = append(rs.values, v)
+		strengthOf[valueKey(v)] = Strength(len(rs.values) - 1)
+	}
+
+	for i, m := range flushMasks {
+		rs.flush[m] = strengthOf[valueKey(flushVals[i])]
+	}
+	// A flush of 6 or 7 cards is the best flush among its 5-card subsets.
+	for n := 6; n <= 7; n++ {
+		for m := 0; m < 1<<13; m++ {
+			if bits.OnesCount16(uint16(m)) != n || m&lowMask != 0 {
+				continue
+			}
+			var best Strength
+			for r := 0; r < 13; r++ {
+				if m&(1<<uint(r)) != 0 {
+					if st := rs.flush[m&^(1<<uint(r))]; st > best {
+						best = st
+					}
+				}
+			}
+			rs.flush[m] = best
+		}
+	}
+
+	rs.noFlush[0] = make([]Strength, quinarySizes[5])
+	for idx, v := range multisetVals {
+		rs.noFlush[0][idx] = strengthOf[valueKey(v)]
+	}
+	for n := 6; n <= 7; n++ {
+		table := make([]Strength, quinarySizes[n])
+		forEachMultiset(n, func(counts *[13]uint8) {
+			var best Strength
+			for r := 0; r < 13; r++ {
+				if counts[r] == 0 {
+					continue
+				}
+				counts[r]--
+				if st := rs.noFlush[n-6][quinaryIndex(counts, n-1)]; st > best {
+					best = st
+				}
+				counts[r]++
+			}
+			table[quinaryIndex(counts, n)] = best
+		})
+		rs.noFlush[n-5] = table
+	}
+	rs.initFrequencies()
+}
+
+// valueKey returns a map key identifying a HandValue.
+func valueKey(v HandValue) string {
+	b := make([]byte, 0, 6)
+	b = append(b, byte(v.Type))
+	for _, x := range v.Values {
+		b = append(b, byte(x))
+	}
+	return string(b)
+}
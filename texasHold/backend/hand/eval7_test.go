@@ -0,0 +1,204 @@
+package hand
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func allCards() []Card {
+	var deck []Card
+	for _, s := range []rune{SuitHeart, SuitSpade, SuitDiamond, SuitClub} {
+		for r := Rank2; r <= RankA; r++ {
+			deck = append(deck, Card{Suit: s, Rank: r})
+		}
+	}
+	return deck
+}
+
+// bruteForceBest is the previous BestHand: Evaluate5 on every 5-card subset.
+func bruteForceBest(cards []Card) HandValue {
+	best := HandValue{Type: HighCard, Values: []int{-1, -1, -1, -1, -1}}
+	for _, idx := range choose5(len(cards)) {
+		five := make([]Card, 5)
+		for i, j := range idx {
+			five[i] = cards[j]
+		}
+		if v := Evaluate5(five); compareHandValues(v, best) > 0 {
+			best = v
+		}
+	}
+	return best
+}
+
+func TestStrengthOrder(t *testing.T) {
+	for s := Strength(1); s < NumStrengths; s++ {
+		if compareHandValues(s.Value(), (s+1).Value()) >= 0 {
+			t.Fatalf("strength %d (%v) not below %d (%v)", s, s.Value(), s+1, (s + 1).Value())
+		}
+	}
+	if (Strength(NumStrengths)).Type() != RoyalFlush {
+		t.Errorf("top strength: got %s, want Royal Flush", Strength(NumStrengths).Type())
+	}
+	for _, rs := range []*Ruleset{Standard, ShortDeck} {
+		for s := Strength(0); int(s) <= rs.NumStrengths()+RankA+1; s++ {
+			if rs.Type(s) != rs.Value(s).Type {
+				t.Fatalf("%s strength %d: Type %s, Value %v", rs.Name, s, rs.Type(s), rs.Value(s))
+			}
+		}
+	}
+}
+
+// TestEvaluate7AllFiveCardHands checks every 5-card hand against Evaluate5.
+func TestEvaluate7AllFiveCardHands(t *testing.T) {
+	if testing.Short() {
+		t.Skip("exhaustive 5-card check")
+	}
+	deck := allCards()
+	five := make([]Card, 5)
+	for a := 0; a < 52; a++ {
+		for b := a + 1; b < 52; b++ {
+			for c := b + 1; c < 52; c++ {
+				for d := c + 1; d < 52; d++ {
+					for e := d + 1; e < 52; e++ {
+						five[0], five[1], five[2], five[3], five[4] = deck[a], deck[b], deck[c], deck[d], deck[e]
+						want := Evaluate5(five)
+						got := Evaluate7(five).Value()
+						if !reflect.DeepEqual(got, want) {
+							t.Fatalf("%v: got %+v, want %+v", five, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestEvaluate7MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	deck := allCards()
+	for i := 0; i < 20000; i++ {
+		rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		n := 5 + i%3
+		cards := deck[:n]
+		want := bruteForceBest(cards)
+		got := Evaluate7(cards).Value()
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v: got %+v, want %+v", cards, got, want)
+		}
+		best, val := BestHand(cards)
+		if !reflect.DeepEqual(val, want) || !reflect.DeepEqual(Evaluate5(best), want) {
+			t.Fatalf("BestHand(%v) = %v %+v, want %+v", cards, best, val, want)
+		}
+	}
+}
+
+func randomSevens(n int) [][]Card {
+	rng := rand.New(rand.NewSource(2))
+	deck := allCards()
+	out := make([][]Card, n)
+	for i := range out {
+		rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		out[i] = append([]Card(nil), deck[:7]...)
+	}
+	return out
+}
+
+func BenchmarkEvaluate7(b *testing.B) {
+	hands := randomSevens(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Evaluate7(hands[i&1023])
+	}
+}
+
+func BenchmarkBruteForce7(b *testing.B) {
+	hands := randomSevens(1024)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		bruteForceBest(hands[i&1023])
+	}
+}
+
+func TestShortDeckEvaluate7MatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	deck := ShortDeck.Cards().Cards()
+	for i := 0; i < 20000; i++ {
+		rng.Shuffle(len(deck), func(i, j int) { deck[i], deck[j] = deck[j], deck[i] })
+		cards := deck[:5+i%3]
+		var want HandValue
+		for k, idx := range choose5(len(cards)) {
+			five := []Card{cards[idx[0]], cards[idx[1]], cards[idx[2]], cards[idx[3]], cards[idx[4]]}
+			if v := ShortDeck.Evaluate5(five); k == 0 || ShortDeck.CompareHandValues(v, want) > 0 {
+				want = v
+			}
+		}
+		if got := ShortDeck.Value(ShortDeck.Evaluate7(cards)); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v: got %+v, want %+v", cards, got, want)
+		}
+	}
+}
+
+func TestEvaluate7InvalidCards(t *testing.T) {
+	five := func(last Card) []Card {
+		cards, _ := ParseCards("HA SA DA CA HK")
+		return append(cards, last)
+	}
+	for _, tt := range []struct {
+		name  string
+		cards []Card
+	}{
+		{"five aces", five(Card{Suit: SuitHeart, Rank: RankA})},
+		{"joker", five(Joker(1))},
+		{"rank above ace", five(Card{Suit: SuitSpade, Rank: RankA + 1})},
+		{"negative rank", five(Card{Suit: SuitSpade, Rank: -1})},
+		{"unknown suit", five(Card{Suit: 'X', Rank: Rank2})},
+	} {
+		if s := Evaluate7(tt.cards); s != 0 {
+			t.Errorf("%s: Evaluate7 = %d, want 0", tt.name, s)
+		}
+		// A joker is always wild; the other cards stay invalid with kings wild.
+		if s := Standard.EvaluateWild(tt.cards, Wilds{Ranks: 1 << RankK}); s != 0 && tt.name != "joker" {
+			t.Errorf("%s: EvaluateWild = %d, want 0", tt.name, s)
+		}
+	}
+}
+
+func TestStrengthClassAndPercentile(t *testing.T) {
+	royal, _ := ParseCards("HT HJ HQ HK HA")
+	worst, _ := ParseCards("H7 S5 D4 C3 H2")
+	if c := Evaluate7(royal).Class(); c != 1 {
+		t.Errorf("royal flush class = %d, want 1", c)
+	}
+	if c := Evaluate7(worst).Class(); c != NumStrengths {
+		t.Errorf("7-5-4-3-2 class = %d, want %d", c, NumStrengths)
+	}
+	if n := Evaluate7(royal).BeatenBy(); n != 0 {
+		t.Errorf("royal flush beaten by %d", n)
+	}
+	// Known frequencies: 40 straight flushes incl. royals, 624 quads, 1,302,540 high cards.
+	var sf, quads, high uint32
+	var total uint64
+	for s := Strength(1); s <= NumStrengths; s++ {
+		n := Standard.freq[s]
+		total += uint64(n)
+		switch s.Type() {
+		case StraightFlush, RoyalFlush:
+			sf += n
+		case FourOfAKind:
+			quads += n
+		case HighCard:
+			high += n
+		}
+	}
+	if total != 2598960 || sf != 40 || quads != 624 || high != 1302540 {
+		t.Errorf("frequencies: total %d, straight flushes %d, quads %d, high cards %d", total, sf, quads, high)
+	}
+	if p := Evaluate7(royal).Percentile(); p < 99.99 || p > 100 {
+		t.Errorf("royal flush percentile = %f", p)
+	}
+	pair, _ := ParseCards("HA SA DK C9 H2")
+	if p := Evaluate7(pair).Percentile(); p < 50 || p > 99 {
+		t.Errorf("pair of aces percentile = %f", p)
+	}
+}
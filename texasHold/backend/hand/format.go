@@ -0,0 +1,107 @@
+package hand
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// CardFormat selects how cards are written.
+type CardFormat int
+
+const (
+	SuitFirst CardFormat = iota // "HA", "S7", "CT" (Card.String)
+	RankFirst                   // "Ah", "7s", "Tc"
+	Unicode                     // "A♥", "7♠", "T♣"
+)
+
+// ParseCardFormat parses a format name: "suit-first" (or ""), "rank-first", "unicode".
+func ParseCardFormat(s string) (CardFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "suit-first":
+		return SuitFirst, nil
+	case "rank-first":
+		return RankFirst, nil
+	case "unicode":
+		return Unicode, nil
+	default:
+		return SuitFirst, fmt.Errorf("unknown card format: %q", s)
+	}
+}
+
+var suitSymbols = map[rune]string{SuitHeart: "♥", SuitSpade: "♠", SuitDiamond: "♦", SuitClub: "♣", SuitAny: "?"}
+
+// Format returns the card in format f.
+func (c Card) Format(f CardFormat) string {
+	if c.IsJoker() {
+		if f == Unicode {
+			return strings.Replace(jokerString(c), "JK", "🃏", 1)
+		}
+		return jokerString(c)
+	}
+	switch f {
+	case RankFirst:
+		return rankToChar(c.Rank) + strings.ToLower(string(c.Suit))
+	case Unicode:
+		return rankToChar(c.Rank) + suitSymbols[c.Suit]
+	default:
+		return c.String()
+	}
+}
+
+// FormatCards returns the cards in format f, separated by spaces.
+func FormatCards(cards []Card, f CardFormat) string {
+	parts := make([]string, len(cards))
+	for i, c := range cards {
+		parts[i] = c.Format(f)
+	}
+	return strings.Join(parts, " ")
+}
+
+// MarshalText implements encoding.TextMarshaler using the suit-first notation.
+func (c Card) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler; it accepts every ParseCard notation.
+func (c *Card) UnmarshalText(b []byte) error {
+	parsed, err := ParseCard(string(b))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler: a card is a JSON string such as "HA".
+func (c Card) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+// UnmarshalJSON implements json.Unmarshaler for a JSON string in any ParseCard notation.
+func (c *Card) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	return c.UnmarshalText([]byte(s))
+}
+
+// MarshalText implements encoding.TextMarshaler with the full description (see Describe).
+func (v HandValue) MarshalText() ([]byte, error) {
+	return []byte(v.Describe()), nil
+}
+
+// MarshalJSON implements json.Marshaler, e.g.
+// {"type":"Full House","values":["K","7"],"description":"Full House, Kings full of Sevens"}.
+func (v HandValue) MarshalJSON() ([]byte, error) {
+	values := make([]string, len(v.Values))
+	for i, r := range v.Values {
+		values[i] = rankToChar(r)
+	}
+	return json.Marshal(struct {
+		Type        string   `json:"type"`
+		Values      []string `json:"values"`
+		Description string   `json:"description"`
+	}{v.Type.String(), values, v.Describe()})
+}
@@ -1,6 +1,8 @@
 package hand
 
 import (
+	"encoding"
+	"encoding/json"
 	"testing"
 )
 
@@ -188,3 +190,263 @@ func TestExcelEdgeCases(t *testing.T) {
 		}
 	})
 }
+
+func TestOmaha(t *testing.T) {
+	// Four hearts on board, one heart in hand: no flush in Omaha (needs 2 hole hearts).
+	hole, _ := ParseCards("HA SK DQ CJ")
+	board, _ := ParseCards("H2 H5 H8 H9 S3")
+	best, val := BestOmahaHand(hole, board)
+	if val.Type == Flush || len(best) != 5 {
+		t.Fatalf("Omaha with one hole heart: got %s %v", val.Type, best)
+	}
+	if got := Holdem.Evaluate(hole[:2], board).Type(); got != Flush {
+		t.Errorf("Hold'em with HA SK on the same board should be a flush, got %s", got)
+	}
+	// Board trips with a pocket pair in hand makes a full house using 2+3.
+	hole, _ = ParseCards("SK DK C4 D7")
+	board, _ = ParseCards("HQ SQ CQ H2 D9")
+	if got := Omaha.Evaluate(hole, board).Type(); got != FullHouse {
+		t.Errorf("Omaha Evaluate: got %s, want Full House", got)
+	}
+	// Five-card PLO uses the same 2+3 rule.
+	hole, _ = ParseCards("HA HK S2 D3 C4")
+	board, _ = ParseCards("HQ HJ HT S9 C8")
+	if got := EvaluateOmaha(hole, board).Type(); got != RoyalFlush {
+		t.Errorf("5-card PLO: got %s, want Royal Flush", got)
+	}
+}
+
+func TestLowEvaluators(t *testing.T) {
+	parse := func(s string) []Card {
+		c, err := ParseCards(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	// Ace-to-five: the wheel is the nuts, straights and flushes do not count.
+	wheel := EvaluateLowA5(parse("HA H2 H3 H4 H5"))
+	sixLow := EvaluateLowA5(parse("S6 D4 C3 H2 SA"))
+	if wheel <= sixLow {
+		t.Errorf("A5: wheel should beat 6-4-3-2-A")
+	}
+	if EvaluateLowA5(parse("SA DA C2 H3 H4")) >= EvaluateLowA5(parse("SK DQ CJ HT H9")) {
+		t.Errorf("A5: any unpaired hand should beat a pair")
+	}
+	if EvaluateLowA5(parse("SA DA C2 H3 H4")) <= EvaluateLowA5(parse("S2 D2 CA H3 H4")) {
+		t.Errorf("A5: pair of aces should beat pair of deuces")
+	}
+	// 8-or-better from 7 cards.
+	if EvaluateLow8(parse("HK SK D8 C7 H5 S3 D2")) == 0 {
+		t.Errorf("8-7-5-3-2 should qualify")
+	}
+	if EvaluateLow8(parse("HK SK D9 C7 H5 S3 D2")) != 0 {
+		t.Errorf("9-low should not qualify")
+	}
+	// Deuce-to-seven: 7-5-4-3-2 is the nuts, the wheel is only ace-high, flushes lose.
+	nuts := EvaluateLow27(parse("H7 S5 D4 C3 H2"))
+	if nuts <= EvaluateLow27(parse("H8 S5 D4 C3 H2")) {
+		t.Errorf("27: 7-5-4-3-2 should beat 8-5-4-3-2")
+	}
+	if EvaluateLow27(parse("HA S5 D4 C3 H2")) <= EvaluateLow27(parse("HA S6 D4 C3 H2")) {
+		t.Errorf("27: A-5-4-3-2 should beat A-6-4-3-2")
+	}
+	if EvaluateLow27(parse("HA S5 D4 C3 H2")) >= EvaluateLow27(parse("HK SQ DJ C9 H8")) {
+		t.Errorf("27: king-high should beat ace-high")
+	}
+	if EvaluateLow27(parse("H7 H5 H4 H3 H2")) >= EvaluateLow27(parse("HK SQ DJ C9 H8")) {
+		t.Errorf("27: a flush should lose to no pair")
+	}
+	if EvaluateLow27(parse("H6 S5 D4 C3 H2")) >= EvaluateLow27(parse("HK SK DJ C9 H8")) {
+		t.Errorf("27: a straight should lose to a pair")
+	}
+}
+
+func TestOmahaHiLoShowdown(t *testing.T) {
+	board, _ := ParseCards("HA H2 S7 DK CQ")
+	p1, _ := ParseCards("SA DA C3 C4") // set of aces, 7-4-3-2-A low
+	p2, _ := ParseCards("S3 D4 HK SK") // set of kings, 7-4-3-2-A low
+	p3, _ := ParseCards("DJ DT C9 C8") // broadway straight, no low
+	highs := []Strength{OmahaHiLo.Evaluate(p1, board), OmahaHiLo.Evaluate(p2, board), OmahaHiLo.Evaluate(p3, board)}
+	lows := []Low{OmahaHiLo.EvaluateLow(p1, board), OmahaHiLo.EvaluateLow(p2, board), OmahaHiLo.EvaluateLow(p3, board)}
+	if lows[0] == 0 || lows[0] != lows[1] || lows[2] != 0 {
+		t.Fatalf("lows: %v", lows)
+	}
+	out := make([]PotShare, 3)
+	Showdown(highs, lows, out)
+	if out[2].Total != 0.5 || out[2].High != 1 || out[2].Low != 0 {
+		t.Errorf("straight should win the high half: %+v", out[2])
+	}
+	if out[0].Total != 0.25 || out[1].Total != 0.25 {
+		t.Errorf("lows should split the low half: %+v %+v", out[0], out[1])
+	}
+	// No qualifying low: high takes the whole pot.
+	Showdown(highs, []Low{0, 0, 0}, out)
+	if !out[2].Scoop || out[2].Total != 1 {
+		t.Errorf("no low: high should scoop, got %+v", out[2])
+	}
+}
+
+func TestShortDeck(t *testing.T) {
+	parse := func(s string) []Card {
+		c, err := ParseCards(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	flush := parse("H6 H8 HT HQ HK")
+	fullHouse := parse("SA DA CA S9 D9")
+	if ShortDeck.CompareHands(flush, fullHouse) != 1 {
+		t.Errorf("short deck: flush should beat full house")
+	}
+	if CompareHands(flush, fullHouse) != -1 {
+		t.Errorf("standard: full house should beat flush")
+	}
+	lowStraight := parse("HA S6 D7 C8 H9")
+	if v := ShortDeck.Evaluate5(lowStraight); v.Type != Straight {
+		t.Fatalf("A-6-7-8-9 should be a straight in short deck, got %s", v.Type)
+	}
+	if ShortDeck.CompareHands(lowStraight, parse("S6 D7 C8 H9 ST")) != -1 {
+		t.Errorf("A-6-7-8-9 should be the lowest straight")
+	}
+	if ShortDeck.CompareHands(lowStraight, parse("SA DA CK HQ SJ")) != 1 {
+		t.Errorf("A-6-7-8-9 straight should beat a pair")
+	}
+	if Evaluate5(lowStraight).Type != HighCard {
+		t.Errorf("A-6-7-8-9 is not a straight in the standard game")
+	}
+	best, val := ShortDeck.BestHand(parse("HA H6 H7 H8 H9 SK DK"))
+	if val.Type != StraightFlush || len(best) != 5 {
+		t.Errorf("short deck BestHand: got %s %v", val.Type, best)
+	}
+	if n := ShortDeck.Cards().Count(); n != 36 {
+		t.Errorf("short deck has %d cards, want 36", n)
+	}
+	d := ShortDeck.NewDeck(NewCardSet(parse("HA SA")...))
+	if d.Remaining() != 34 {
+		t.Errorf("short deck Remaining = %d, want 34", d.Remaining())
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	cases := []struct {
+		cards string
+		want  string
+	}{
+		{"SK HK DK S7 D7", "Full House, Kings full of Sevens"},
+		{"HA H9 H7 H4 H2", "Ace-high flush"},
+		{"SQ HQ D5 C5 HA", "Two Pairs, Queens and Fives"},
+		{"HA S2 D3 C4 H5", "Five-high straight"},
+		{"S6 H6 DK C9 H2", "Pair of Sixes"},
+		{"DT DJ DQ DK DA", "Royal Flush"},
+		{"CA SK S9 D6 H4", "Ace-high"},
+	}
+	for _, tc := range cases {
+		cards, _ := ParseCards(tc.cards)
+		if got := Evaluate5(cards).Describe(); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.cards, got, tc.want)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	explain := func(a, b string) Explanation {
+		ca, _ := ParseCards(a)
+		cb, _ := ParseCards(b)
+		return Explain(ca, Evaluate5(ca), cb, Evaluate5(cb))
+	}
+	e := explain("SQ HQ D5 C5 HA", "DQ CQ S5 H5 SK")
+	if e.Winner != 1 || e.Step != 3 || !e.Kicker || e.RankA != RankA || len(e.CardsA) != 1 || e.CardsA[0].Rank != RankA {
+		t.Errorf("kicker: %+v", e)
+	}
+	if got, want := e.Text("hand1", "hand2"), "both Two Pairs, Queens and Fives; hand1 wins on the Ace kicker"; got != want {
+		t.Errorf("Text: got %q, want %q", got, want)
+	}
+	e = explain("H3 S4 C5 S6 D7", "DQ CQ S5 H5 SK")
+	if e.Winner != 1 || e.Step != 0 || e.Text("hand1", "hand2") != "hand1's Seven-high straight beats hand2's Two Pairs, Queens and Fives" {
+		t.Errorf("type: %+v %q", e, e.Text("hand1", "hand2"))
+	}
+	e = explain("SK HK DK S7 D7", "SA HA DA S2 D2")
+	if e.Winner != -1 || e.Step != 1 || e.Kicker || len(e.CardsB) != 3 {
+		t.Errorf("full house: %+v", e)
+	}
+	e = explain("CA SK S9 D6 H4", "HA SK S9 D6 H4")
+	if e.Winner != 0 || e.Step != -1 || e.Text("hand1", "hand2") != "tie: both have Ace-high" {
+		t.Errorf("tie: %+v", e)
+	}
+}
+
+func TestParseCardNotations(t *testing.T) {
+	cases := map[string]Card{
+		"HA":  {Suit: SuitHeart, Rank: RankA},
+		"Ah":  {Suit: SuitHeart, Rank: RankA},
+		"td":  {Suit: SuitDiamond, Rank: RankT},
+		"10h": {Suit: SuitHeart, Rank: RankT},
+		"H10": {Suit: SuitHeart, Rank: RankT},
+		"A♠":  {Suit: SuitSpade, Rank: RankA},
+		"♦K":  {Suit: SuitDiamond, Rank: RankK},
+		"7♣️": {Suit: SuitClub, Rank: Rank7},
+		"q♡":  {Suit: SuitHeart, Rank: RankQ},
+	}
+	for s, want := range cases {
+		got, err := ParseCard(s)
+		if err != nil || got != want {
+			t.Errorf("ParseCard(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	for _, s := range []string{"HAX", "A", "X7", "1h", "AZ"} {
+		if _, err := ParseCard(s); err == nil {
+			t.Errorf("ParseCard(%q): expected error", s)
+		}
+	}
+	cards, err := ParseCards("[Ah, Kd] 10♥ S7AcJs")
+	if err != nil || FormatCards(cards, SuitFirst) != "HA DK HT S7 CA SJ" {
+		t.Errorf("ParseCards: %v %v", cards, err)
+	}
+}
+
+func TestCardFormatAndJSON(t *testing.T) {
+	c := Card{Suit: SuitHeart, Rank: RankT}
+	if c.Format(RankFirst) != "Th" || c.Format(Unicode) != "T♥" || c.Format(SuitFirst) != "HT" {
+		t.Errorf("Format: %q %q %q", c.Format(RankFirst), c.Format(Unicode), c.Format(SuitFirst))
+	}
+	b, err := json.Marshal([]Card{c, {Suit: SuitSpade, Rank: RankA}})
+	if err != nil || string(b) != `["HT","SA"]` {
+		t.Errorf("json.Marshal cards: %s %v", b, err)
+	}
+	var back []Card
+	if err := json.Unmarshal([]byte(`["Th","A♠"]`), &back); err != nil || len(back) != 2 || back[0] != c {
+		t.Errorf("json.Unmarshal cards: %v %v", back, err)
+	}
+	cards, _ := ParseCards("SK HK DK S7 D7")
+	b, err = json.Marshal(Evaluate5(cards))
+	if err != nil || string(b) != `{"type":"Full House","values":["K","7"],"description":"Full House, Kings full of Sevens"}` {
+		t.Errorf("json.Marshal HandValue: %s %v", b, err)
+	}
+	var _ encoding.TextMarshaler = Card{}
+	var _ encoding.TextMarshaler = HandValue{}
+}
+
+func TestStudAndRazz(t *testing.T) {
+	for name, want := range map[string]Variant{"stud": SevenCardStud, "7stud": SevenCardStud, "Razz": Razz} {
+		if got, err := ParseVariant(name); err != nil || got != want || !got.Stud() {
+			t.Errorf("ParseVariant(%q) = %v, %v", name, got, err)
+		}
+	}
+	seven, _ := ParseCards("SK HK D7 C5 H4 S3 DA")
+	if got := SevenCardStud.Evaluate(seven, nil).Type(); got != OnePair {
+		t.Errorf("stud hand: %v, want One Pair", got)
+	}
+	low := Razz.BestLowHand(seven, nil)
+	if d := DescribeLow(low); d != "7-5-4-3-A low" {
+		t.Errorf("razz low: %s", d)
+	}
+	paired, _ := ParseCards("SK HK DK C5 H5 S3 D3")
+	if Razz.EvaluateLow(paired, nil) >= Razz.EvaluateLow(seven, nil) {
+		t.Errorf("paired razz hand should lose to a seven low")
+	}
+	if d := DescribeLow(Razz.BestLowHand(paired, nil)); d != "K-5-5-3-3 low" {
+		t.Errorf("paired razz low: %s", d)
+	}
+}
@@ -0,0 +1,139 @@
+package hand
+
+// SuitPermutation relabels suits: p[i] is the suit index (H, S, D, C = 0..3) that
+// suit index i becomes. Suits are strategically symmetric, so permuting them maps a
+// situation to an equivalent one.
+type SuitPermutation [4]uint8
+
+// IdentitySuits leaves every suit unchanged.
+var IdentitySuits = SuitPermutation{0, 1, 2, 3}
+
+// Apply returns c with its suit relabeled by p. Jokers are returned unchanged.
+func (p SuitPermutation) Apply(c Card) Card {
+	if s := suitIndex(c.Suit); s >= 0 {
+		c.Suit = indexSuits[p[s]]
+	}
+	return c
+}
+
+// ApplyAll returns the cards relabeled by p.
+func (p SuitPermutation) ApplyAll(cards []Card) []Card {
+	out := make([]Card, len(cards))
+	for i, c := range cards {
+		out[i] = p.Apply(c)
+	}
+	return out
+}
+
+// Inverse returns the permutation that undoes p.
+func (p SuitPermutation) Inverse() SuitPermutation {
+	var inv SuitPermutation
+	for i, s := range p {
+		inv[s] = uint8(i)
+	}
+	return inv
+}
+
+// suitPermutations holds all 24 permutations, the identity first.
+var suitPermutations = func() []SuitPermutation {
+	var out []SuitPermutation
+	for a := uint8(0); a < 4; a++ {
+		for b := uint8(0); b < 4; b++ {
+			for c := uint8(0); c < 4; c++ {
+				d := 6 - a - b - c
+				if a != b && a != c && b != c && d < 4 && d != a && d != b && d != c {
+					out = append(out, SuitPermutation{a, b, c, d})
+				}
+			}
+		}
+	}
+	return out
+}()
+
+// Canonicalize maps hole cards and a board to a canonical representative of their
+// class under suit permutation, e.g. AhKh on 2c 7c 9d and AsKs on 2d 7d 9h give the
+// same result. The hole cards and the board are each unordered sets (the order of
+// board cards does not change a showdown); the canonical cards of each are sorted by
+// rank (high first), then suit.
+//
+// perm maps the original suits to the canonical ones (canonical = perm.Apply(original));
+// perm.Inverse() maps results computed on the canonical form back to the input suits.
+// With hole cards only there are 169 classes; boards alone give 1,755 flops,
+// 16,432 turns and 134,459 rivers.
+func Canonicalize(hole, board []Card) (canonHole, canonBoard []Card, perm SuitPermutation) {
+	var best, cur [7]uint8 // suit indices of the mapped cards, in canonical order
+	n := len(hole) + len(board)
+	if n > len(cur) {
+		n = len(cur)
+	}
+	h := make([]Card, len(hole))
+	b := make([]Card, len(board))
+	for k, p := range suitPermutations {
+		mapIsomorph(p, hole, board, h, b)
+		for i := 0; i < n; i++ {
+			if i < len(h) {
+				cur[i] = uint8(suitIndex(h[i].Suit))
+			} else {
+				cur[i] = uint8(suitIndex(b[i-len(h)].Suit))
+			}
+		}
+		if k == 0 || lessSuits(cur[:n], best[:n]) {
+			best, perm = cur, p
+		}
+	}
+	mapIsomorph(perm, hole, board, h, b)
+	return h, b, perm
+}
+
+// CanonicalKey returns a string identifying the suit-isomorphism class of hole cards and
+// board, e.g. "HAHK|H2H7S9", for use as a cache or table key.
+func CanonicalKey(hole, board []Card) string {
+	h, b, _ := Canonicalize(hole, board)
+	key := make([]byte, 0, 2*(len(h)+len(b))+1)
+	for _, c := range h {
+		key = append(key, c.String()...)
+	}
+	key = append(key, '|')
+	for _, c := range b {
+		key = append(key, c.String()...)
+	}
+	return string(key)
+}
+
+// mapIsomorph writes the cards relabeled by p into h and b and sorts each of them
+// by rank, high first, then suit index.
+func mapIsomorph(p SuitPermutation, hole, board, h, b []Card) {
+	for i, c := range hole {
+		h[i] = p.Apply(c)
+	}
+	for i, c := range board {
+		b[i] = p.Apply(c)
+	}
+	sortGroup(h)
+	sortGroup(b)
+}
+
+// sortGroup is an insertion sort; groups have at most 5 cards.
+func sortGroup(cards []Card) {
+	for i := 1; i < len(cards); i++ {
+		for j := i; j > 0 && groupLess(cards[j], cards[j-1]); j-- {
+			cards[j], cards[j-1] = cards[j-1], cards[j]
+		}
+	}
+}
+
+func groupLess(a, b Card) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	return suitIndex(a.Suit) < suitIndex(b.Suit)
+}
+
+func lessSuits(a, b []uint8) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return false
+}
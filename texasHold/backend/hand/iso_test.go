@@ -0,0 +1,90 @@
+package hand
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestCanonicalizeExample(t *testing.T) {
+	h1, _ := ParseCards("Ah Kh")
+	b1, _ := ParseCards("2c 7c 9d")
+	h2, _ := ParseCards("Ks As")
+	b2, _ := ParseCards("9h 2d 7d")
+	if k1, k2 := CanonicalKey(h1, b1), CanonicalKey(h2, b2); k1 != k2 || k1 != "HAHK|S9D7D2" {
+		t.Errorf("keys %q and %q, want both %q", k1, k2, "HAHK|S9D7D2")
+	}
+	b3, _ := ParseCards("2c 7d 9d")
+	if CanonicalKey(h1, b1) == CanonicalKey(h1, b3) {
+		t.Errorf("different flush draws share a key")
+	}
+}
+
+func TestCanonicalizePermutation(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 1000; i++ {
+		deck := NewDeck(0)
+		deck.Shuffle(rng)
+		hole := []Card{deck.Deal(), deck.Deal()}
+		board := make([]Card, rng.IntN(6))
+		for j := range board {
+			board[j] = deck.Deal()
+		}
+		ch, cb, perm := Canonicalize(hole, board)
+		// perm maps the input onto the canonical form, and its inverse maps it back.
+		if NewCardSet(perm.ApplyAll(hole)...) != NewCardSet(ch...) {
+			t.Fatalf("perm %v does not map %v to %v", perm, hole, ch)
+		}
+		if back := perm.Inverse().ApplyAll(cb); NewCardSet(back...) != NewCardSet(board...) {
+			t.Fatalf("inverse perm maps board %v back to %v, want %v", cb, back, board)
+		}
+		// A random relabeling lands on the same class.
+		p := suitPermutations[rng.IntN(len(suitPermutations))]
+		if CanonicalKey(p.ApplyAll(hole), p.ApplyAll(board)) != CanonicalKey(hole, board) {
+			t.Fatalf("relabeled %v %v by %v changes the key", hole, board, p)
+		}
+	}
+}
+
+func TestCanonicalClassCounts(t *testing.T) {
+	all := make([]Card, 52)
+	for i := range all {
+		all[i] = CardFromIndex(i)
+	}
+	preflop := make(map[string]bool)
+	for i := 0; i < 52; i++ {
+		for j := i + 1; j < 52; j++ {
+			preflop[CanonicalKey([]Card{all[i], all[j]}, nil)] = true
+		}
+	}
+	if len(preflop) != 169 {
+		t.Errorf("%d preflop classes, want 169", len(preflop))
+	}
+	// Every board extends a representative of some smaller board class, so growing the
+	// representatives one card at a time reaches every class.
+	reps := [][]Card{nil}
+	want := []int{1755, 16432, 134459}
+	for size := 1; size <= 5; size++ {
+		if testing.Short() && size > 3 {
+			break
+		}
+		seen := make(map[string]bool)
+		var next [][]Card
+		for _, b := range reps {
+			used := NewCardSet(b...)
+			for _, c := range all {
+				if used.Contains(c) {
+					continue
+				}
+				board := append(append([]Card(nil), b...), c)
+				if key := CanonicalKey(nil, board); !seen[key] {
+					seen[key] = true
+					next = append(next, board)
+				}
+			}
+		}
+		reps = next
+		if size >= 3 && len(reps) != want[size-3] {
+			t.Errorf("%d classes of %d-card boards, want %d", len(reps), size, want[size-3])
+		}
+	}
+}
@@ -0,0 +1,242 @@
+package hand
+
+import (
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+// Low is the value of a lowball hand. Like Strength, higher is better, so the best
+// low wins a comparison; 0 means no (qualifying) low.
+type Low uint32
+
+// Ace-to-five lows are keyed by pattern (no pair, pair, two pairs, trips, full house,
+// quads) and then by the group ranks in base 13 with the ace as the lowest rank.
+const (
+	lowPatterns = 6
+	lowBase     = 13 * 13 * 13 * 13 * 13
+	maxLowA5    = lowPatterns * lowBase
+)
+
+// lowRank maps a rank to ace-to-five order: A=0, 2=1, ..., K=12.
+func lowRank(r int) int {
+	return (r + 1) % 13
+}
+
+// lowA5Of5 returns the ace-to-five low of exactly 5 cards. Straights and flushes do
+// not count, aces are low, and pairs count against the hand.
+func lowA5Of5(five []Card) Low {
+	var counts [13]uint8
+	for _, c := range five {
+		counts[lowRank(c.Rank)]++
+	}
+	// Groups ordered by count, then rank, highest first.
+	pattern := 0
+	key := 0
+	digits := 0
+	for n := 4; n >= 1; n-- {
+		for r := 12; r >= 0; r-- {
+			if int(counts[r]) != n {
+				continue
+			}
+			key = key*13 + r
+			digits++
+			switch {
+			case n == 4:
+				pattern = 5
+			case n == 3 && pattern == 0:
+				pattern = 3
+			case n == 2 && pattern == 3:
+				pattern = 4
+			case n == 2:
+				pattern++
+			}
+		}
+	}
+	for ; digits < 5; digits++ {
+		key *= 13
+	}
+	return Low(maxLowA5 - (pattern*lowBase + key))
+}
+
+// EvaluateLowA5 returns the best ace-to-five low among 5 to 7 cards (pairs allowed,
+// as in Razz). Returns 0 for fewer than 5 or more than 7 cards.
+func EvaluateLowA5(cards []Card) Low {
+	return bestLowOf(cards, lowAceToFive)
+}
+
+// DescribeLow describes an ace-to-five low of 5 cards from its highest card down,
+// e.g. "7-5-4-3-A low" or "8-6-3-3-2 low" (pairs count against the hand).
+func DescribeLow(five []Card) string {
+	ranks := make([]int, len(five))
+	for i, c := range five {
+		ranks[i] = lowRank(c.Rank)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(ranks)))
+	parts := make([]string, len(ranks))
+	for i, r := range ranks {
+		parts[i] = rankToChar((r + 12) % 13)
+	}
+	return strings.Join(parts, "-") + " low"
+}
+
+// EvaluateLow8 returns the best ace-to-five low among 5 to 7 cards that qualifies as
+// 8-or-better (five distinct ranks, all 8 or lower), or 0 if there is none.
+func EvaluateLow8(cards []Card) Low {
+	l := EvaluateLowA5(cards)
+	if !qualifiesLow8(l) {
+		return 0
+	}
+	return l
+}
+
+// qualifiesLow8 reports whether an ace-to-five low is an unpaired 8-low or better.
+func qualifiesLow8(l Low) bool {
+	key := maxLowA5 - int(l)
+	// No pair and the highest card is at most 8 (low rank 7).
+	return l != 0 && key < lowBase && key/(13*13*13*13) <= 7
+}
+
+// lowDeuceToSevenOf5 returns the deuce-to-seven low of exactly 5 cards: aces are high,
+// straights and flushes count against the hand, and A-2-3-4-5 is not a straight.
+func lowDeuceToSevenOf5(five []Card) Low {
+	s := Evaluate7(five)
+	key := 2 * int(s)
+	switch s {
+	case wheelStraight:
+		// Ace-high: just below A-6-4-3-2, above every king-high hand.
+		key = 2*int(aceSixHigh) - 1
+	case wheelStraightFlush:
+		key = 2*int(aceSixFlush) - 1
+	}
+	return Low(2*NumStrengths + 1 - key)
+}
+
+// EvaluateLow27 returns the best deuce-to-seven low among 5 to 7 cards.
+func EvaluateLow27(cards []Card) Low {
+	return bestLowOf(cards, lowDeuceToSeven)
+}
+
+// lowEval selects the 5-card evaluator of a lowball game. A switch rather than a
+// function value keeps the five cards on the stack, so evaluating does not allocate.
+type lowEval int
+
+const (
+	lowAceToFive lowEval = iota
+	lowDeuceToSeven
+)
+
+// of5 returns the low of exactly 5 cards.
+func (e lowEval) of5(five []Card) Low {
+	if e == lowDeuceToSeven {
+		return lowDeuceToSevenOf5(five)
+	}
+	return lowA5Of5(five)
+}
+
+// bestLowOf returns the best value of eval over all 5-card subsets of 5 to 7 cards.
+func bestLowOf(cards []Card, eval lowEval) Low {
+	best, _ := bestLowSubset(cards, eval)
+	return best
+}
+
+// bestLowSubset returns the best value of eval over all 5-card subsets of 5 to 7 cards
+// and a bitmask of the cards left out to reach it.
+func bestLowSubset(cards []Card, eval lowEval) (best Low, bestSkip int) {
+	n := len(cards)
+	if n < 5 || n > 7 {
+		return 0, 0
+	}
+	var five [5]Card
+	for skip := 0; skip < 1<<uint(n); skip++ {
+		if bits.OnesCount(uint(skip)) != n-5 {
+			continue
+		}
+		k := 0
+		for x := 0; x < n; x++ {
+			if skip&(1<<uint(x)) == 0 {
+				five[k] = cards[x]
+				k++
+			}
+		}
+		if l := eval.of5(five[:]); l > best {
+			best, bestSkip = l, skip
+		}
+	}
+	return best, bestSkip
+}
+
+// bestLowCards returns the 5 cards of the best low among 5 to 7 cards, or nil if there is none.
+func bestLowCards(cards []Card, eval lowEval) []Card {
+	best, skip := bestLowSubset(cards, eval)
+	if best == 0 {
+		return nil
+	}
+	out := make([]Card, 0, 5)
+	for x := range cards {
+		if skip&(1<<uint(x)) == 0 {
+			out = append(out, cards[x])
+		}
+	}
+	return out
+}
+
+// EvaluateOmahaLow8 returns the best 8-or-better low using exactly 2 hole cards and
+// exactly 3 board cards, or 0 if there is none.
+func EvaluateOmahaLow8(hole, board []Card) Low {
+	best, _, _ := bestOmahaLow8(hole, board)
+	return best
+}
+
+// BestOmahaLowHand returns the 5 cards (2 hole + 3 board) of the best Omaha 8-or-better low.
+func BestOmahaLowHand(hole, board []Card) []Card {
+	best, h, b := bestOmahaLow8(hole, board)
+	if best == 0 {
+		return nil
+	}
+	return []Card{hole[h[0]], hole[h[1]], board[b[0]], board[b[1]], board[b[2]]}
+}
+
+func bestOmahaLow8(hole, board []Card) (best Low, bestHole [2]int, bestBoard [3]int) {
+	var five [5]Card
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			five[0], five[1] = hole[i], hole[j]
+			for a := 0; a < len(board); a++ {
+				for b := a + 1; b < len(board); b++ {
+					for c := b + 1; c < len(board); c++ {
+						five[2], five[3], five[4] = board[a], board[b], board[c]
+						if l := lowA5Of5(five[:]); l > best && qualifiesLow8(l) {
+							best = l
+							bestHole = [2]int{i, j}
+							bestBoard = [3]int{a, b, c}
+						}
+					}
+				}
+			}
+		}
+	}
+	return best, bestHole, bestBoard
+}
+
+var (
+	wheelStraight      Strength // A-2-3-4-5 offsuit
+	wheelStraightFlush Strength // A-2-3-4-5 suited
+	aceSixHigh         Strength // A-6-4-3-2 offsuit
+	aceSixFlush        Strength // A-6-4-3-2 suited
+)
+
+// initLowTables looks up the strengths deuce-to-seven needs; called after the strength tables exist.
+func initLowTables() {
+	strengthOf := func(s string) Strength {
+		cards, err := ParseCards(s)
+		if err != nil {
+			panic(err)
+		}
+		return Evaluate7(cards)
+	}
+	wheelStraight = strengthOf("HA S2 D3 C4 H5")
+	wheelStraightFlush = strengthOf("HA H2 H3 H4 H5")
+	aceSixHigh = strengthOf("HA S6 D4 C3 H2")
+	aceSixFlush = strengthOf("HA H6 H4 H3 H2")
+}
@@ -0,0 +1,43 @@
+package hand
+
+// EvaluateOmaha returns the strength of the best Omaha hand, which must use exactly
+// 2 of the hole cards (4 or 5 for PLO/5-card PLO) and exactly 3 of the board cards (3 to 5).
+// Returns 0 if there are fewer than 2 hole or 3 board cards.
+func EvaluateOmaha(hole, board []Card) Strength {
+	best, _, _ := bestOmaha(hole, board)
+	return best
+}
+
+// BestOmahaHand returns the best 5-card Omaha hand (2 hole + 3 board) and its value.
+func BestOmahaHand(hole, board []Card) ([]Card, HandValue) {
+	best, h, b := bestOmaha(hole, board)
+	if best == 0 {
+		return nil, HandValue{}
+	}
+	five := []Card{hole[h[0]], hole[h[1]], board[b[0]], board[b[1]], board[b[2]]}
+	return five, best.Value()
+}
+
+// bestOmaha tries every 2-card hole and 3-card board combination and returns the best
+// strength with the hole and board indices that make it.
+func bestOmaha(hole, board []Card) (best Strength, bestHole [2]int, bestBoard [3]int) {
+	var five [5]Card
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			five[0], five[1] = hole[i], hole[j]
+			for a := 0; a < len(board); a++ {
+				for b := a + 1; b < len(board); b++ {
+					for c := b + 1; c < len(board); c++ {
+						five[2], five[3], five[4] = board[a], board[b], board[c]
+						if s := Evaluate7(five[:]); s > best {
+							best = s
+							bestHole = [2]int{i, j}
+							bestBoard = [3]int{a, b, c}
+						}
+					}
+				}
+			}
+		}
+	}
+	return best, bestHole, bestBoard
+}
@@ -12,6 +12,7 @@ const (
 	FourOfAKind
 	StraightFlush
 	RoyalFlush
+	FiveOfAKind // only with wild cards (see Wilds)
 )
 
 // HandType value for comparison.
@@ -39,6 +40,8 @@ func (h HandType) String() string {
 		return "Straight Flush"
 	case RoyalFlush:
 		return "Royal Flush"
+	case FiveOfAKind:
+		return "Five of a Kind"
 	default:
 		return "Unknown"
 	}
@@ -0,0 +1,102 @@
+package hand
+
+// Ruleset is a deck and hand ranking. Standard is the 52-card game; ShortDeck (6+)
+// removes the 2s to 5s, plays A-6-7-8-9 as the lowest straight and ranks a flush
+// above a full house.
+type Ruleset struct {
+	Name                string
+	MinRank             int  // lowest rank in the deck; the ace-low straight is A + MinRank..MinRank+3
+	FlushBeatsFullHouse bool // flush ranks above full house
+
+	// values[s] is the HandValue of strength s (index 0 unused).
+	values []HandValue
+	// flush maps a 13-bit rank mask of one suit (5+ bits) to the best flush or straight flush.
+	flush [1 << 13]Strength
+	// noFlush[k-5] maps the quinary index of a k-card rank multiset to its best non-flush hand.
+	noFlush [3][]Strength
+	// freq[s] is the number of 5-card hands with strength s; below[s] the number weaker than s.
+	freq  []uint32
+	below []uint64
+}
+
+var (
+	// Standard is the 52-card deck with the usual hand ranking.
+	Standard = &Ruleset{Name: "standard", MinRank: Rank2}
+	// ShortDeck is 6+ Hold'em: 36 cards, A-6-7-8-9 straight, flush beats full house.
+	ShortDeck = &Ruleset{Name: "short-deck", MinRank: Rank6, FlushBeatsFullHouse: true}
+)
+
+// typeOrder returns the position of t in rs's ranking of hand types.
+func (rs *Ruleset) typeOrder(t HandType) int {
+	if rs.FlushBeatsFullHouse {
+		switch t {
+		case Flush:
+			return int(FullHouse)
+		case FullHouse:
+			return int(Flush)
+		}
+	}
+	return int(t)
+}
+
+// CompareHandValues compares two hand values under rs. Returns -1 if a<b, 0 if a==b, 1 if a>b.
+func (rs *Ruleset) CompareHandValues(a, b HandValue) int {
+	if a.Type != b.Type {
+		if rs.typeOrder(a.Type) > rs.typeOrder(b.Type) {
+			return 1
+		}
+		return -1
+	}
+	return compareHandValues(a, b)
+}
+
+// Cards returns the set of cards in rs's deck.
+func (rs *Ruleset) Cards() CardSet {
+	var s CardSet
+	for _, suit := range indexSuits {
+		for r := rs.MinRank; r <= RankA; r++ {
+			s = s.Add(Card{Suit: suit, Rank: r})
+		}
+	}
+	return s
+}
+
+// NewDeck returns an unshuffled deck of rs's cards except those in dead.
+func (rs *Ruleset) NewDeck(dead CardSet) *Deck {
+	return NewDeck(dead | (FullCardSet &^ rs.Cards()))
+}
+
+// BestHand returns the best 5-card hand from 5 to 7 cards under rs.
+func (rs *Ruleset) BestHand(cards []Card) ([]Card, HandValue) {
+	if len(cards) < 5 {
+		return nil, HandValue{}
+	}
+	best := rs.Evaluate7(cards)
+	if len(cards) == 5 {
+		return append([]Card(nil), cards...), rs.Value(best)
+	}
+	var bestHand []Card
+	five := make([]Card, 5)
+	for _, idx := range choose5(len(cards)) {
+		for i, j := range idx {
+			five[i] = cards[j]
+		}
+		if rs.Evaluate7(five) == best {
+			bestHand = append([]Card(nil), five...)
+			break
+		}
+	}
+	return bestHand, rs.Value(best)
+}
+
+// CompareHands compares two hands of 5 to 7 cards under rs. Returns -1 if a<b, 0 if a==b, 1 if a>b.
+func (rs *Ruleset) CompareHands(a, b []Card) int {
+	sa, sb := rs.Evaluate7(a), rs.Evaluate7(b)
+	if sa > sb {
+		return 1
+	}
+	if sa < sb {
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,53 @@
+package hand
+
+// PotShare is what one player wins at showdown, as fractions of the pot.
+type PotShare struct {
+	High  float64 // share of the high half (of the whole pot if nobody has a low)
+	Low   float64 // share of the low half
+	Total float64 // fraction of the whole pot
+	Scoop bool    // won the whole pot alone
+}
+
+// Showdown divides a pot among players with the given high strengths and lows and
+// writes each player's share to out (len(out) >= len(highs)). Pass nil lows for
+// high-only games. If no player has a qualifying low (all 0), the best high hands take
+// the whole pot; otherwise each half is split evenly among the players tied for it.
+func Showdown(highs []Strength, lows []Low, out []PotShare) {
+	var bestHigh Strength
+	nHigh := 0
+	for _, h := range highs {
+		if h > bestHigh {
+			bestHigh, nHigh = h, 1
+		} else if h == bestHigh {
+			nHigh++
+		}
+	}
+	var bestLow Low
+	nLow := 0
+	for _, l := range lows {
+		if l == 0 {
+			continue
+		}
+		if l > bestLow {
+			bestLow, nLow = l, 1
+		} else if l == bestLow {
+			nLow++
+		}
+	}
+	highPot := 1.0
+	if nLow > 0 {
+		highPot = 0.5
+	}
+	for i, h := range highs {
+		s := PotShare{}
+		if h == bestHigh {
+			s.High = 1 / float64(nHigh)
+		}
+		if nLow > 0 && lows[i] == bestLow {
+			s.Low = 1 / float64(nLow)
+		}
+		s.Total = highPot*s.High + (1-highPot)*s.Low
+		s.Scoop = s.Total == 1
+		out[i] = s
+	}
+}
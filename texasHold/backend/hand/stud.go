@@ -0,0 +1,18 @@
+package hand
+
+// StudHand is one player's cards in a stud game (SevenCardStud, Razz). Down cards are
+// seen only by the player, so an opponent's are usually unknown and left out; Up cards
+// are seen by everybody. A player has seven cards at showdown.
+type StudHand struct {
+	Down, Up []Card
+}
+
+// Known returns the player's known cards, down cards first.
+func (h StudHand) Known() []Card {
+	return append(append([]Card(nil), h.Down...), h.Up...)
+}
+
+// Missing returns how many cards the player still gets to reach seven.
+func (h StudHand) Missing() int {
+	return 7 - len(h.Down) - len(h.Up)
+}
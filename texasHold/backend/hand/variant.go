@@ -0,0 +1,145 @@
+package hand
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Variant is a poker game: it decides how many hole cards a player holds and
+// how hole and board cards combine into a hand.
+type Variant int
+
+const (
+	Holdem          Variant = iota // Texas Hold'em: best 5 of 2 hole + board
+	Omaha                          // Pot-Limit Omaha: exactly 2 of 4 or 5 hole + exactly 3 of board
+	OmahaHiLo                      // Omaha Hi/Lo: Omaha high splits the pot with the best 8-or-better low
+	ShortDeckHoldem                // Short-deck (6+) Hold'em: Hold'em with the ShortDeck ruleset
+	SevenCardStud                  // Seven-card Stud: best 5 of each player's own 7 cards, no board
+	Razz                           // Razz: Seven-card Stud for the best ace-to-five low, pairs count
+)
+
+func (v Variant) String() string {
+	switch v {
+	case Holdem:
+		return "holdem"
+	case Omaha:
+		return "omaha"
+	case OmahaHiLo:
+		return "omaha-hilo"
+	case ShortDeckHoldem:
+		return "short-deck"
+	case SevenCardStud:
+		return "stud"
+	case Razz:
+		return "razz"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseVariant parses a game name as used in API requests. The empty string is Hold'em.
+func ParseVariant(s string) (Variant, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "holdem", "texas-holdem", "nlhe":
+		return Holdem, nil
+	case "omaha", "plo", "plo5":
+		return Omaha, nil
+	case "omaha-hilo", "omaha8", "plo8":
+		return OmahaHiLo, nil
+	case "short-deck", "shortdeck", "6plus", "6+":
+		return ShortDeckHoldem, nil
+	case "stud", "seven-card-stud", "7stud":
+		return SevenCardStud, nil
+	case "razz":
+		return Razz, nil
+	default:
+		return Holdem, fmt.Errorf("unknown game: %q", s)
+	}
+}
+
+// HoleCards returns the allowed number of hole cards per player. In stud games these
+// are all of a player's own cards, down and up, at showdown (5 to 7, with no board).
+func (v Variant) HoleCards() (min, max int) {
+	switch v {
+	case Omaha, OmahaHiLo:
+		return 4, 5
+	case SevenCardStud, Razz:
+		return 5, 7
+	}
+	return 2, 2
+}
+
+// Stud reports whether v deals each player their own seven cards instead of using a board.
+func (v Variant) Stud() bool {
+	return v == SevenCardStud || v == Razz
+}
+
+// Lowball reports whether v awards the whole pot to the best low instead of the best high.
+func (v Variant) Lowball() bool {
+	return v == Razz
+}
+
+// ValidHoleCount reports whether a player may hold n hole cards in v.
+func (v Variant) ValidHoleCount(n int) bool {
+	min, max := v.HoleCards()
+	return n >= min && n <= max
+}
+
+// Rules returns the deck and hand ranking v is played with.
+func (v Variant) Rules() *Ruleset {
+	if v == ShortDeckHoldem {
+		return ShortDeck
+	}
+	return Standard
+}
+
+// HiLo reports whether v splits each pot between the best high and the best qualifying low.
+func (v Variant) HiLo() bool {
+	return v == OmahaHiLo
+}
+
+// Evaluate returns the strength of the best high hand from hole and board cards under v's rules.
+// It does not allocate.
+func (v Variant) Evaluate(hole, board []Card) Strength {
+	if v == Omaha || v == OmahaHiLo {
+		return EvaluateOmaha(hole, board)
+	}
+	var buf [7]Card
+	n := copy(buf[:], hole)
+	n += copy(buf[n:], board)
+	return v.Rules().Evaluate7(buf[:n])
+}
+
+// BestHand returns the best 5-card hand from hole and board cards under v's rules.
+func (v Variant) BestHand(hole, board []Card) ([]Card, HandValue) {
+	if v == Omaha || v == OmahaHiLo {
+		return BestOmahaHand(hole, board)
+	}
+	return v.Rules().BestHand(append(append([]Card(nil), hole...), board...))
+}
+
+// EvaluateLow returns the best qualifying low for hi/lo variants, or 0 if there is none
+// or v has no low half. For Razz it is the ace-to-five low that wins the pot.
+func (v Variant) EvaluateLow(hole, board []Card) Low {
+	switch v {
+	case OmahaHiLo:
+		return EvaluateOmahaLow8(hole, board)
+	case Razz:
+		var buf [7]Card
+		n := copy(buf[:], hole)
+		n += copy(buf[n:], board)
+		return EvaluateLowA5(buf[:n])
+	}
+	return 0
+}
+
+// BestLowHand returns the 5 cards of the best qualifying low for hi/lo variants and Razz, or nil.
+func (v Variant) BestLowHand(hole, board []Card) []Card {
+	switch v {
+	case OmahaHiLo:
+		return BestOmahaLowHand(hole, board)
+	case Razz:
+		return bestLowCards(append(append([]Card(nil), hole...), board...), lowAceToFive)
+	}
+	return nil
+}
@@ -0,0 +1,357 @@
+package hand
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Wilds describes the wild cards of a game: how many jokers the deck holds (0 to 2) and
+// which ranks are wild (a bitmask over ranks, e.g. 1<<Rank2 for deuces wild). Jokers are
+// always wild. A wild card stands for any card, even one already in the hand, so with
+// wild cards five of a kind beats a royal flush.
+type Wilds struct {
+	Jokers int
+	Ranks  uint16
+}
+
+// DeucesWild makes every 2 wild.
+var DeucesWild = Wilds{Ranks: 1 << Rank2}
+
+// None reports whether w has no wild cards.
+func (w Wilds) None() bool {
+	return w.Jokers == 0 && w.Ranks == 0
+}
+
+// IsWild reports whether c is wild under w.
+func (w Wilds) IsWild(c Card) bool {
+	return c.IsJoker() || w.Ranks&(1<<uint(c.Rank)) != 0
+}
+
+// String describes w, e.g. "1 joker, 2s wild".
+func (w Wilds) String() string {
+	var parts []string
+	switch w.Jokers {
+	case 0:
+	case 1:
+		parts = append(parts, "1 joker")
+	default:
+		parts = append(parts, fmt.Sprintf("%d jokers", w.Jokers))
+	}
+	for r := Rank2; r <= RankA; r++ {
+		if w.Ranks&(1<<uint(r)) != 0 {
+			parts = append(parts, rankToChar(r)+"s wild")
+		}
+	}
+	if len(parts) == 0 {
+		return "no wild cards"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ParseWildRanks parses wild ranks such as "2" or "2,J" (also "10", "deuces") into a mask.
+func ParseWildRanks(ranks []string) (uint16, error) {
+	var mask uint16
+	for _, s := range ranks {
+		s = strings.TrimSpace(s)
+		if strings.EqualFold(s, "deuces") {
+			s = "2"
+		}
+		r, n, err := scanRank(s)
+		if err != nil || n != len(s) {
+			return 0, fmt.Errorf("invalid wild rank: %q", s)
+		}
+		mask |= 1 << uint(r)
+	}
+	return mask, nil
+}
+
+// NewWildDeck returns an unshuffled deck of rs's cards plus w's jokers, except those in dead.
+func (rs *Ruleset) NewWildDeck(dead CardSet, w Wilds) *Deck {
+	live := rs.Cards()
+	for i := 1; i <= w.Jokers && i <= 2; i++ {
+		live = live.Add(Joker(i))
+	}
+	return newDeckOf(live &^ dead)
+}
+
+// EvaluateWild is EvaluateWild under the standard ruleset.
+func EvaluateWild(cards []Card, w Wilds) Strength {
+	return Standard.EvaluateWild(cards, w)
+}
+
+// EvaluateWild returns the strength of the best 5-card hand within 5 to 7 cards when
+// the wild cards (see Wilds) take their best substitutes. Five of a kind is stronger
+// than every other strength of rs (see Ruleset.Value). Without wild cards in the hand
+// it equals rs.Evaluate7; like it, it returns 0 for an invalid card.
+func (rs *Ruleset) EvaluateWild(cards []Card, w Wilds) Strength {
+	n := len(cards)
+	if n < 5 || n > 7 {
+		return 0
+	}
+	var counts [13]uint8
+	var masks [4]uint16
+	k := 0
+	for _, c := range cards {
+		if w.IsWild(c) {
+			k++
+			continue
+		}
+		s := suitIndex(c.Suit)
+		if s < 0 || c.Rank < Rank2 || c.Rank > RankA || counts[c.Rank] == 4 {
+			return 0
+		}
+		counts[c.Rank]++
+		masks[s] |= 1 << uint(c.Rank)
+	}
+	if k == 0 {
+		return rs.Evaluate7(cards)
+	}
+
+	// Five of a kind: the highest rank the wilds can bring to five.
+	for r := RankA; r >= rs.MinRank; r-- {
+		if int(counts[r])+k >= 5 {
+			return rs.fiveOfAKind(r)
+		}
+	}
+
+	var best Strength
+	deckRanks := uint16(1<<13-1) &^ (1<<uint(rs.MinRank) - 1)
+	for s := 0; s < 4; s++ {
+		m := masks[s]
+		if bits.OnesCount16(m)+k < 5 {
+			continue
+		}
+		// Straight flush: the highest five-rank window the wilds can complete.
+		for hi := RankA; hi >= rs.MinRank+3; hi-- {
+			window := straightWindow(hi, rs.MinRank)
+			if bits.OnesCount16(window&^m) <= k {
+				if st := rs.flush[window]; st > best {
+					best = st
+				}
+				break
+			}
+		}
+		// Flush: the wilds become the highest ranks missing from the suit.
+		fill := m
+		for r, left := RankA, k; r >= rs.MinRank && left > 0; r-- {
+			if fill&(1<<uint(r)) == 0 {
+				fill |= 1 << uint(r)
+				left--
+			}
+		}
+		if st := rs.flush[fill&deckRanks]; st > best {
+			best = st
+		}
+	}
+	// Everything else depends only on ranks: try every rank multiset for the wilds.
+	rs.forEachWildRanks(&counts, rs.MinRank, k, func() {
+		if st := rs.noFlush[n-5][quinaryIndex(&counts, n)]; st > best {
+			best = st
+		}
+	})
+	return best
+}
+
+// fiveOfAKind returns the strength of five cards of rank r under rs.
+func (rs *Ruleset) fiveOfAKind(r int) Strength {
+	return Strength(len(rs.values) + r)
+}
+
+// straightWindow returns the rank mask of the straight with high card hi; a high card
+// of minRank+3 is the wheel, which plays the ace low.
+func straightWindow(hi, minRank int) uint16 {
+	if hi == minRank+3 {
+		return 1<<uint(RankA) | (1<<4-1)<<uint(minRank)
+	}
+	return (1<<5 - 1) << uint(hi-4)
+}
+
+// forEachWildRanks adds k more cards to counts, at most 4 per rank and only ranks from
+// `from` up, calling f for each multiset. counts is restored afterwards.
+func (rs *Ruleset) forEachWildRanks(counts *[13]uint8, from, k int, f func()) {
+	if k == 0 {
+		f()
+		return
+	}
+	for r := from; r <= RankA; r++ {
+		if counts[r] < 4 {
+			counts[r]++
+			rs.forEachWildRanks(counts, r, k-1, f)
+			counts[r]--
+		}
+	}
+}
+
+// BestWildHand returns the best 5 cards among 5 to 7 cards with wild cards, the same five
+// as played (each wild replaced by the card it stands for, or by a StandIn when no card
+// of that rank is left), and their value under rs.
+func (rs *Ruleset) BestWildHand(cards []Card, w Wilds) (best, played []Card, val HandValue) {
+	target := rs.EvaluateWild(cards, w)
+	if target == 0 {
+		return nil, nil, HandValue{}
+	}
+	five := make([]Card, 5)
+	for _, idx := range choose5(len(cards)) {
+		for i, j := range idx {
+			five[i] = cards[j]
+		}
+		if rs.EvaluateWild(five, w) == target {
+			val = rs.Value(target)
+			return five, rs.substitute(five, w, val), val
+		}
+	}
+	return nil, nil, HandValue{}
+}
+
+// substitute returns five with its wild cards replaced so that the cards make val. A
+// wild never repeats a card of the hand: once all four of a rank are there, as in five
+// of a kind, it becomes StandIn of the rank.
+func (rs *Ruleset) substitute(five []Card, w Wilds, val HandValue) []Card {
+	played := append([]Card(nil), five...)
+	var wilds []int
+	var have CardSet
+	var counts [13]int
+	flushSuit := rune(0)
+	for i, c := range five {
+		if w.IsWild(c) {
+			wilds = append(wilds, i)
+			continue
+		}
+		have = have.Add(c)
+		counts[c.Rank]++
+		flushSuit = c.Suit
+	}
+	if len(wilds) == 0 {
+		return played
+	}
+
+	// The ranks the hand needs, as a multiset; the wilds take what the naturals lack.
+	var need [13]int
+	switch val.Type {
+	case FiveOfAKind:
+		need[val.Values[0]] = 5
+	case RoyalFlush, StraightFlush, Straight:
+		hi := RankA
+		if val.Type != RoyalFlush {
+			hi = val.Values[0]
+		}
+		window := straightWindow(hi, rs.MinRank)
+		for r := 0; r < 13; r++ {
+			if window&(1<<uint(r)) != 0 {
+				need[r] = 1
+			}
+		}
+	case FourOfAKind:
+		need[val.Values[0]], need[val.Values[1]] = 4, 1
+	case FullHouse:
+		need[val.Values[0]], need[val.Values[1]] = 3, 2
+	case ThreeOfAKind:
+		need[val.Values[0]] = 3
+		need[val.Values[1]]++
+		need[val.Values[2]]++
+	case TwoPairs:
+		need[val.Values[0]], need[val.Values[1]] = 2, 2
+		need[val.Values[2]]++
+	case OnePair:
+		need[val.Values[0]] = 2
+		for _, r := range val.Values[1:] {
+			need[r]++
+		}
+	default: // HighCard, Flush
+		for _, r := range val.Values {
+			need[r]++
+		}
+	}
+	suited := val.Type == Flush || val.Type == StraightFlush || val.Type == RoyalFlush
+	wi := 0
+	for r := RankA; r >= Rank2 && wi < len(wilds); r-- {
+		for ; counts[r] < need[r] && wi < len(wilds); counts[r]++ {
+			c := Card{Suit: flushSuit, Rank: r}
+			if !suited {
+				c = spareCard(r, have, flushSuit)
+			}
+			if !c.IsStandIn() {
+				have = have.Add(c)
+			}
+			played[wilds[wi]] = c
+			wi++
+		}
+	}
+	return played
+}
+
+// spareCard returns a card of rank r missing from have, preferably not of suit avoid
+// (so that it does not make a flush), or StandIn(r) when have holds all four.
+func spareCard(r int, have CardSet, avoid rune) Card {
+	spare := StandIn(r)
+	for _, s := range indexSuits {
+		if c := (Card{Suit: s, Rank: r}); !have.Contains(c) {
+			if s != avoid {
+				return c
+			}
+			spare = c
+		}
+	}
+	return spare
+}
+
+// EvaluateWild returns the strength of the best high hand from hole and board cards
+// under v's rules with wild cards w.
+func (v Variant) EvaluateWild(hole, board []Card, w Wilds) Strength {
+	if w.None() {
+		return v.Evaluate(hole, board)
+	}
+	if v == Omaha || v == OmahaHiLo {
+		var best Strength
+		var five [5]Card
+		forEachOmaha5(hole, board, &five, func() {
+			if st := Standard.EvaluateWild(five[:], w); st > best {
+				best = st
+			}
+		})
+		return best
+	}
+	var buf [7]Card
+	n := copy(buf[:], hole)
+	n += copy(buf[n:], board)
+	return v.Rules().EvaluateWild(buf[:n], w)
+}
+
+// BestWildHand returns the best 5 cards from hole and board with wild cards w, the
+// five as played (wilds replaced) and their value under v's rules.
+func (v Variant) BestWildHand(hole, board []Card, w Wilds) (best, played []Card, val HandValue) {
+	if v == Omaha || v == OmahaHiLo {
+		target := v.EvaluateWild(hole, board, w)
+		var five [5]Card
+		forEachOmaha5(hole, board, &five, func() {
+			if best == nil && Standard.EvaluateWild(five[:], w) == target {
+				best = append([]Card(nil), five[:]...)
+			}
+		})
+		if best == nil {
+			return nil, nil, HandValue{}
+		}
+		val = Standard.Value(target)
+		return best, Standard.substitute(best, w, val), val
+	}
+	return v.Rules().BestWildHand(append(append([]Card(nil), hole...), board...), w)
+}
+
+// forEachOmaha5 fills five with every hand of exactly 2 hole and 3 board cards in turn,
+// calling f after each. The caller owns five, so it can stay on the caller's stack.
+func forEachOmaha5(hole, board []Card, five *[5]Card, f func()) {
+	for i := 0; i < len(hole); i++ {
+		for j := i + 1; j < len(hole); j++ {
+			five[0], five[1] = hole[i], hole[j]
+			for a := 0; a < len(board); a++ {
+				for b := a + 1; b < len(board); b++ {
+					for c := b + 1; c < len(board); c++ {
+						five[2], five[3], five[4] = board[a], board[b], board[c]
+						f()
+					}
+				}
+			}
+		}
+	}
+}
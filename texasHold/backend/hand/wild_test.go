@@ -0,0 +1,193 @@
+package hand
+
+import (
+	"math/rand/v2"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestJokerNotation(t *testing.T) {
+	cards, err := ParseCards("JK, joker2 🃏 JKAh JK2H")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Card{Joker(1), Joker(2), Joker(1), Joker(1), {Suit: SuitHeart, Rank: RankA}, Joker(1), {Suit: SuitHeart, Rank: Rank2}}
+	if len(cards) != len(want) {
+		t.Fatalf("ParseCards: %v, want %v", cards, want)
+	}
+	for i := range want {
+		if cards[i] != want[i] {
+			t.Errorf("card %d = %v, want %v", i, cards[i], want[i])
+		}
+	}
+	if Joker(2).String() != "JK2" || Joker(1).Format(Unicode) != "🃏" || CardFromIndex(Joker(2).Index()) != Joker(2) {
+		t.Errorf("joker formatting or index")
+	}
+	if d := Standard.NewWildDeck(0, Wilds{Jokers: 2}); d.Remaining() != 54 {
+		t.Errorf("deck with 2 jokers has %d cards", d.Remaining())
+	}
+	if d := ShortDeck.NewWildDeck(NewCardSet(Joker(1)), Wilds{Jokers: 1}); d.Remaining() != 36 {
+		t.Errorf("short deck with its joker dead has %d cards", d.Remaining())
+	}
+}
+
+func TestEvaluateWildExamples(t *testing.T) {
+	cases := []struct {
+		cards  string
+		wilds  Wilds
+		typ    HandType
+		played string
+	}{
+		{"HA SA DA CA JK", Wilds{Jokers: 1}, FiveOfAKind, "HA SA DA CA ?A"},
+		{"HK HQ HJ HT JK", Wilds{Jokers: 1}, RoyalFlush, "HK HQ HJ HT HA"},
+		{"H9 S9 D2 C2 H5", DeucesWild, FourOfAKind, "H9 S9 D9 C9 H5"},
+		{"H3 H7 D2 SK CJ", DeucesWild, OnePair, "H3 H7 HK SK CJ"},
+		{"H3 H7 H9 HJ SK C2 D8", DeucesWild, Flush, "H3 H7 H9 HJ HA"},
+		{"H3 H7 H9 HJ SK C4 D8", DeucesWild, HighCard, "H7 H9 HJ SK D8"},
+		{"JK JK2 C2 HA HK", Wilds{Jokers: 2, Ranks: 1 << Rank2}, RoyalFlush, ""},
+		{"JK JK2 C2 HA SA", Wilds{Jokers: 2, Ranks: 1 << Rank2}, FiveOfAKind, ""},
+		{"H2 S2 D2 C2 H7 S7 DK", DeucesWild, FiveOfAKind, "HK SK CK ?K DK"},
+		{"JK JK2 H2 S2 D2", Wilds{Jokers: 2, Ranks: 1 << Rank2}, FiveOfAKind, "HA SA DA CA ?A"},
+	}
+	for _, tc := range cases {
+		cards, err := ParseCards(tc.cards)
+		if err != nil {
+			t.Fatal(err)
+		}
+		st := EvaluateWild(cards, tc.wilds)
+		if got := st.Type(); got != tc.typ {
+			t.Errorf("%s (%v): %v, want %v", tc.cards, tc.wilds, got, tc.typ)
+			continue
+		}
+		_, played, val := Standard.BestWildHand(cards, tc.wilds)
+		if val.Type != tc.typ {
+			t.Errorf("%s: BestWildHand %v, want %v", tc.cards, val.Type, tc.typ)
+		}
+		if tc.played != "" {
+			got, want := strings.Fields(FormatCards(played, SuitFirst)), strings.Fields(tc.played)
+			sort.Strings(got)
+			sort.Strings(want)
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("%s: played %v, want %s", tc.cards, FormatCards(played, SuitFirst), tc.played)
+			}
+		}
+		for _, c := range played {
+			if c.IsJoker() {
+				t.Errorf("%s: played hand %v still has a joker", tc.cards, played)
+			}
+		}
+	}
+	five := EvaluateWild(mustCards(t, "JK HA SA DA CA"), Wilds{Jokers: 1})
+	if five <= EvaluateWild(mustCards(t, "HA HK HQ HJ HT"), Wilds{}) || five.Value().Describe() != "Five of a Kind, Aces" {
+		t.Errorf("five aces %v should beat a royal flush", five.Value())
+	}
+	fives := EvaluateWild(mustCards(t, "JK HK SK DK CK"), Wilds{Jokers: 1})
+	if c := Standard.Class(five); c != -13 || Standard.Class(fives) != -12 || Standard.BeatenBy(fives) != 1 || Standard.Percentile(fives) != 100 {
+		t.Errorf("five aces class %d; five kings class %d, beaten by %d, percentile %v",
+			c, Standard.Class(fives), Standard.BeatenBy(fives), Standard.Percentile(fives))
+	}
+	if StandIn(RankK).Format(RankFirst) != "K?" || StandIn(RankK).Format(Unicode) != "K?" {
+		t.Errorf("stand-in formatting: %s", StandIn(RankK).Format(RankFirst))
+	}
+}
+
+func mustCards(t *testing.T, s string) []Card {
+	t.Helper()
+	cards, err := ParseCards(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cards
+}
+
+// bruteForceWild tries every card for every wild, including cards already in the hand.
+// The wilds are interchangeable, so each takes a card no lower than the wild before.
+func bruteForceWild(rs *Ruleset, cards []Card, w Wilds) Strength {
+	var best Strength
+	hand := append([]Card(nil), cards...)
+	var deck []Card
+	rs.Cards().ForEach(func(c Card) { deck = append(deck, c) })
+	var rec func(i, from int)
+	rec = func(i, from int) {
+		if i == len(hand) {
+			var counts [13]int
+			for _, c := range hand {
+				counts[c.Rank]++
+			}
+			for r, n := range counts {
+				if n >= 5 {
+					if st := rs.fiveOfAKind(r); st > best {
+						best = st
+					}
+					return
+				}
+			}
+			if st := rs.Evaluate7(hand); st > best {
+				best = st
+			}
+			return
+		}
+		if !w.IsWild(cards[i]) {
+			rec(i+1, from)
+			return
+		}
+		for j := from; j < len(deck); j++ {
+			hand[i] = deck[j]
+			rec(i+1, j)
+		}
+		hand[i] = cards[i]
+	}
+	rec(0, 0)
+	return best
+}
+
+func TestEvaluateWildBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	games := []struct {
+		rs *Ruleset
+		w  Wilds
+	}{
+		{Standard, Wilds{Jokers: 1}},
+		{Standard, Wilds{Jokers: 2}},
+		{Standard, DeucesWild},
+		{ShortDeck, Wilds{Jokers: 1, Ranks: 1 << Rank6}},
+	}
+	for _, g := range games {
+		deck := g.rs.NewWildDeck(0, g.w)
+		for i := 0; i < 300; i++ {
+			deck.Shuffle(rng)
+			n := 5 + i%3
+			cards := make([]Card, n)
+			wilds := 0
+			for j := range cards {
+				cards[j] = deck.Deal()
+				if g.w.IsWild(cards[j]) {
+					wilds++
+				}
+			}
+			if got, want := g.rs.EvaluateWild(cards, g.w), bruteForceWild(g.rs, cards, g.w); got != want {
+				t.Fatalf("%s %v: EvaluateWild %v (%v), brute force %v (%v)", g.rs.Name, cards, got, g.rs.Value(got), want, g.rs.Value(want))
+			}
+		}
+		// Random hands rarely hold three wilds or more: deal every wild first.
+		var wild, natural []Card
+		for d := g.rs.NewWildDeck(0, g.w); d.Remaining() > 0; {
+			if c := d.Deal(); g.w.IsWild(c) {
+				wild = append(wild, c)
+			} else {
+				natural = append(natural, c)
+			}
+		}
+		for i := 0; i < 30; i++ {
+			n := 5 + i%3
+			k := min(3+i%3, len(wild), n)
+			rng.Shuffle(len(wild), func(a, b int) { wild[a], wild[b] = wild[b], wild[a] })
+			rng.Shuffle(len(natural), func(a, b int) { natural[a], natural[b] = natural[b], natural[a] })
+			cards := append(append([]Card(nil), wild[:k]...), natural[:n-k]...)
+			if got, want := g.rs.EvaluateWild(cards, g.w), bruteForceWild(g.rs, cards, g.w); got != want {
+				t.Fatalf("%s %v: EvaluateWild %v (%v), brute force %v (%v)", g.rs.Name, cards, got, g.rs.Value(got), want, g.rs.Value(want))
+			}
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"texashold-backend/api"
 )
 
@@ -12,11 +13,21 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
+	if s := os.Getenv("EXACT_THRESHOLD"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil || n < 0 {
+			log.Fatalf("invalid EXACT_THRESHOLD %q", s)
+		}
+		api.ExactThreshold = n
+	}
 
 	http.HandleFunc("/api/evaluate", api.HandleEvaluate)
 	http.HandleFunc("/api/compare", api.HandleCompare)
 	http.HandleFunc("/api/win-probability", api.HandleWinProbability)
 	http.HandleFunc("/api/win-probability-multi", api.HandleWinProbabilityMulti)
+	http.HandleFunc("/api/win-probability-multi/stream", api.HandleWinProbabilityMultiStream)
+	http.HandleFunc("/api/outs", api.HandleOuts)
+	http.HandleFunc("/api/preflop-grid", api.HandlePreflopGrid)
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		_, _ = w.Write([]byte(`{"status":"healthy"}`))
@@ -0,0 +1,144 @@
+package montecarlo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// Simulator runs simulations in parallel. The simulations are split into chunks of
+// chunkSims, each drawing from its own random stream derived from Seed and the chunk's
+// index, and chunk results are combined in chunk order: a Simulator gives the same
+// results for the same Seed and RNG whatever the number of workers.
+type Simulator struct {
+	Seed    uint64 // master seed
+	RNG     RNG    // generator of the streams; the zero value is PCG
+	Workers int    // goroutines to use; 0 for runtime.GOMAXPROCS(0)
+
+	// TargetMargin stops WinProbability and WinProbabilityMulti early once every 95%
+	// confidence half-width (see Result.Margin) is at most this; 0 runs every simulation.
+	TargetMargin float64
+
+	// HandTypes makes WinProbability, WinProbabilityMulti and RangeEquity collect each
+	// player's made-hand distribution in Result.HandTypes, under plain sampling only.
+	HandTypes bool
+
+	// Sampling is the variance-reduction strategy of WinProbability,
+	// WinProbabilityMulti and RangeEquity; runs it does not apply to sample plainly. Result.Sampling says which
+	// was used.
+	Sampling Sampling
+
+	// Progress, when set, is called by WinProbability, WinProbabilityMulti and
+	// RangeEquity with the result so far each time they check TargetMargin, every
+	// roundChunks chunks but the last, on the caller's goroutine.
+	Progress func(Result)
+}
+
+// defaultSimulator is used by the package-level functions: a random seed and a worker
+// per CPU.
+func defaultSimulator() Simulator {
+	return Simulator{Seed: NewSeed()}
+}
+
+// chunkSims is the number of simulations in one chunk, the unit of work of a worker.
+const chunkSims = 1024
+
+// trial runs one simulation, drawing from r and adding its outcome to tally.
+type trial func(r *rand.Rand, tally []float64)
+
+// workers returns how many goroutines run nChunks chunks.
+func (s Simulator) workers(nChunks int) int {
+	n := s.Workers
+	if n <= 0 {
+		n = runtime.GOMAXPROCS(0)
+	}
+	return max(1, min(n, nChunks))
+}
+
+// roundChunks is the number of chunks between checks of a stopping rule. It does not
+// depend on the number of workers, so neither does where a run stops.
+const roundChunks = 32
+
+// run runs nSims trials and returns the sum of their tallies, each of width n.
+// newTrial is called at the start of each chunk: a trial owns its deck and buffers,
+// and a fresh deck keeps a chunk's shuffles independent of the chunks run before it.
+//
+// The run stops early when ctx is done (after at least one chunk) or when done, checked
+// every roundChunks chunks, reports that the sums of sims trials are precise enough;
+// done may be nil. It returns how many trials ran and why it stopped.
+func (s Simulator) run(ctx context.Context, nSims, n int, newTrial func() trial, done func(sums []float64, sims int) bool) (sums []float64, sims int, stop StopReason) {
+	nChunks := (nSims + chunkSims - 1) / chunkSims
+	tallies := make([]float64, nChunks*n)
+	ran := make([]bool, nChunks)
+	sums = make([]float64, n)
+	for start := 0; start < nChunks; start += roundChunks {
+		end := min(nChunks, start+roundChunks)
+		s.runChunks(ctx, start, end, nSims, n, tallies, ran, newTrial)
+		skipped := false
+		for c := start; c < end; c++ {
+			if !ran[c] {
+				skipped = true
+				continue
+			}
+			for i := range sums {
+				sums[i] += tallies[c*n+i]
+			}
+			sims += min(nSims, (c+1)*chunkSims) - c*chunkSims
+		}
+		switch {
+		case skipped && errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return sums, sims, StopDeadline
+		case skipped:
+			return sums, sims, StopCancelled
+		case end < nChunks && done != nil && done(sums, sims):
+			return sums, sims, StopTargetMargin
+		}
+	}
+	return sums, sims, StopCompleted
+}
+
+// doneAt returns run's done for a run reporting result: it passes each result to
+// s.Progress and stops once s.TargetMargin is reached.
+func (s Simulator) doneAt(result func(sums []float64, sims int) Result) func(sums []float64, sims int) bool {
+	if s.TargetMargin <= 0 && s.Progress == nil {
+		return nil
+	}
+	return func(sums []float64, sims int) bool {
+		res := result(sums, sims)
+		if s.Progress != nil {
+			s.Progress(res)
+		}
+		return s.TargetMargin > 0 && res.Margin() <= s.TargetMargin
+	}
+}
+
+// runChunks runs chunks start to end-1 on s's workers, writing each chunk's tally and
+// marking it in ran. Once ctx is done the remaining chunks are skipped, except chunk 0.
+func (s Simulator) runChunks(ctx context.Context, start, end, nSims, n int, tallies []float64, ran []bool, newTrial func() trial) {
+	next := atomic.Int64{}
+	next.Store(int64(start))
+	var wg sync.WaitGroup
+	for w := s.workers(end - start); w > 0; w-- {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				c := int(next.Add(1)) - 1
+				if c >= end || c > 0 && ctx.Err() != nil {
+					return
+				}
+				r := rand.New(s.RNG.stream(s.Seed, uint64(c)))
+				t := newTrial()
+				tally := tallies[c*n : (c+1)*n]
+				for i := c * chunkSims; i < min(nSims, (c+1)*chunkSims); i++ {
+					t(r, tally)
+				}
+				ran[c] = true
+			}
+		}()
+	}
+	wg.Wait()
+}
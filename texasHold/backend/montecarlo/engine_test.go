@@ -0,0 +1,176 @@
+package montecarlo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"texashold-backend/hand"
+	"texashold-backend/ranges"
+)
+
+func TestSimulatorDeterministic(t *testing.T) {
+	ctx := context.Background()
+	hole := cards(t, "HA HK")
+	board := cards(t, "H9 H5 C2")
+	holes := [][]hand.Card{hole, cards(t, "S9 D9"), cards(t, "DQ DJ")}
+	hiLo := [][]hand.Card{cards(t, "HA H2 S3 SK"), cards(t, "SQ DQ HJ DJ")}
+	studs := []hand.StudHand{{Down: cards(t, "HA H2"), Up: cards(t, "D3")}, {Up: cards(t, "SK")}}
+	// Not a multiple of the chunk size, so the last chunk is partial.
+	const nSims = 5*chunkSims + 123
+	type results struct {
+		single    Result
+		multi     Result
+		hiLo      HiLoResult
+		multiHiLo []HiLoResult
+		stud      Result
+		wild      Result
+	}
+	var first results
+	for _, workers := range []int{1, 2, 3, 8} {
+		s := Simulator{Seed: 42, Workers: workers}
+		var r results
+		r.single = s.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, board, 3, nSims)
+		r.multi = s.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, nSims)
+		r.hiLo, _, _ = s.WinProbabilityHiLo(ctx, hand.OmahaHiLo, hiLo[0], nil, 4, nSims)
+		r.multiHiLo, _, _ = s.WinProbabilityMultiHiLo(ctx, hand.OmahaHiLo, hiLo, nil, nSims)
+		r.stud = s.StudWinProbability(ctx, hand.Razz, studs, nil, nSims)
+		r.wild = s.WinProbability(ctx, hand.Holdem, hand.DeucesWild, hole, nil, 2, nSims)
+		if workers == 1 {
+			first = r
+			continue
+		}
+		if !reflect.DeepEqual(r, first) {
+			t.Errorf("%d workers: %+v, want %+v as with 1 worker", workers, r, first)
+		}
+	}
+
+	// Another seed gives other results.
+	res := Simulator{Seed: 43}.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, board, 3, nSims)
+	if win := res.Players[0].Win.Value; win == first.single.Players[0].Win.Value {
+		t.Errorf("seeds 42 and 43 both give %v", win)
+	}
+	// The simulation still converges: compare with the exact result, seat by seat.
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, p := range exact.Players {
+		q := first.multi.Players[i]
+		if math.Abs(q.Win.Value-p.Win.Value) > 0.03 || math.Abs(q.Tie.Value-p.Tie.Value) > 0.01 {
+			t.Errorf("player %d: simulated %+v, exact %+v", i, q, p)
+		}
+	}
+}
+
+func BenchmarkSimulatorWorkers(b *testing.B) {
+	hole := []hand.Card{{Suit: hand.SuitHeart, Rank: hand.RankA}, {Suit: hand.SuitHeart, Rank: hand.RankK}}
+	maxWorkers := runtime.GOMAXPROCS(0)
+	for workers := 1; ; workers *= 2 {
+		workers = min(workers, maxWorkers)
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			s := Simulator{Seed: 1, Workers: workers}
+			for i := 0; i < b.N; i++ {
+				s.WinProbability(context.Background(), hand.Holdem, hand.Wilds{}, hole, nil, 6, 100000)
+			}
+		})
+		if workers == maxWorkers {
+			return
+		}
+	}
+}
+
+func TestSimulatorRNGs(t *testing.T) {
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9")}
+	for _, name := range []string{"pcg", "chacha8", "crypto"} {
+		g, err := ParseRNG(name)
+		if err != nil || g.String() != name {
+			t.Fatalf("ParseRNG(%q) = %v, %v", name, g, err)
+		}
+		a := Simulator{Seed: 7, RNG: g}.WinProbabilityMulti(context.Background(), hand.Holdem, hand.Wilds{}, holes, nil, 3000)
+		b := Simulator{Seed: 7, RNG: g, Workers: 3}.WinProbabilityMulti(context.Background(), hand.Holdem, hand.Wilds{}, holes, nil, 3000)
+		if g.Deterministic() != reflect.DeepEqual(a, b) {
+			t.Errorf("%s: runs with the same seed give %+v and %+v", name, a, b)
+		}
+		if win := a.Players[1].Win.Value; math.Abs(win-0.55) > 0.05 {
+			t.Errorf("%s: nines win %v, want about 0.55", name, win)
+		}
+	}
+	if _, err := ParseRNG("mt19937"); err == nil {
+		t.Error("ParseRNG accepted mt19937")
+	}
+}
+
+// simulation is one simulation path, run with nSims simulations on one worker.
+type simulation struct {
+	name string
+	run  func(nSims int)
+}
+
+func simulations(tb testing.TB) []simulation {
+	ctx := context.Background()
+	s := Simulator{Seed: 1, Workers: 1}
+	types := Simulator{Seed: 1, Workers: 1, HandTypes: true}
+	stratified := Simulator{Seed: 1, Workers: 1, Sampling: StratifiedSampling}
+	antithetic := Simulator{Seed: 1, Workers: 1, Sampling: AntitheticSampling}
+	importance := Simulator{Seed: 1, Workers: 1, Sampling: ImportanceSampling}
+	hole, flop := cards(tb, "HA HK"), cards(tb, "H9 H5 C2")
+	holes := [][]hand.Card{hole, cards(tb, "S9 D9"), cards(tb, "DQ DJ")}
+	omaha := [][]hand.Card{cards(tb, "HA H2 S3 SK"), cards(tb, "SQ DQ HJ DJ")}
+	players := []Player{{Hole: hole}, {Range: ranges.MustParse("22+, ATs+, KQo")}, {}}
+	twoRanges := []Player{{Hole: hole}, {Range: ranges.MustParse("22+, ATs+, KQo")}, {Range: ranges.MustParse("TT+, AQs+")}}
+	studs := []hand.StudHand{{Down: cards(tb, "HA H2"), Up: cards(tb, "D3")}, {Up: cards(tb, "SK")}, {}}
+	return []simulation{
+		{"holdem", func(n int) { s.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, nil, 6, n) }},
+		{"holdem_hand_types", func(n int) { types.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, flop, 6, n) }},
+		{"short_deck", func(n int) { s.WinProbability(ctx, hand.ShortDeckHoldem, hand.Wilds{}, hole, nil, 6, n) }},
+		{"wild", func(n int) { s.WinProbability(ctx, hand.Holdem, hand.Wilds{Jokers: 2}, hole, nil, 6, n) }},
+		{"omaha_wild", func(n int) { s.WinProbability(ctx, hand.Omaha, hand.DeucesWild, omaha[0], nil, 4, n) }},
+		{"multi_hand_types", func(n int) { types.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, flop, n) }},
+		{"ranges", func(n int) { s.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, n) }},
+		{"stratified", func(n int) { stratified.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, flop, n) }},
+		{"antithetic", func(n int) { antithetic.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, nil, n) }},
+		{"stratified_single", func(n int) { stratified.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, flop, 3, n) }},
+		{"antithetic_single", func(n int) { antithetic.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, nil, 3, n) }},
+		{"importance", func(n int) { importance.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, twoRanges, nil, n) }},
+		{"hilo", func(n int) { s.WinProbabilityHiLo(ctx, hand.OmahaHiLo, omaha[0], nil, 4, n) }},
+		{"multi_hilo", func(n int) { s.WinProbabilityMultiHiLo(ctx, hand.OmahaHiLo, omaha, nil, n) }},
+		{"stud", func(n int) { s.StudWinProbability(ctx, hand.SevenCardStud, studs, nil, n) }},
+		{"razz", func(n int) { s.StudWinProbability(ctx, hand.Razz, studs, nil, n) }},
+	}
+}
+
+// allocsPerSim returns the allocations of one simulation of sim: the allocations of a
+// chunk of simulations less those of a chunk of one, whose setup is the same.
+func allocsPerSim(sim simulation) float64 {
+	one := testing.AllocsPerRun(20, func() { sim.run(1) })
+	chunk := testing.AllocsPerRun(20, func() { sim.run(chunkSims) })
+	return (chunk - one) / (chunkSims - 1)
+}
+
+func TestSimulationsDoNotAllocate(t *testing.T) {
+	for _, sim := range simulations(t) {
+		if a := allocsPerSim(sim); a != 0 {
+			t.Errorf("%s: %v allocs per simulation", sim.name, a)
+		}
+	}
+}
+
+// BenchmarkSimulations times one simulation of each path; run with -benchmem. Setup
+// is spread over b.N simulations, so allocs/op is 0 once b.N is large, and the
+// benchmark fails if a simulation itself allocates.
+func BenchmarkSimulations(b *testing.B) {
+	for _, sim := range simulations(b) {
+		b.Run(sim.name, func(b *testing.B) {
+			if a := allocsPerSim(sim); a != 0 {
+				b.Fatalf("%v allocs per simulation, want 0", a)
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			sim.run(b.N)
+		})
+	}
+}
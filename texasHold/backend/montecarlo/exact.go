@@ -0,0 +1,322 @@
+package montecarlo
+
+import (
+	"context"
+	"errors"
+
+	"texashold-backend/hand"
+)
+
+// ErrInvalidInput is returned by the exact functions for hands or boards they cannot
+// enumerate: wrong card counts, duplicate cards or too few cards left in the deck.
+var ErrInvalidInput = errors.New("montecarlo: invalid hands or board")
+
+// ExactCombinations returns the number of runouts an exact computation visits: the ways
+// to complete the board with boardCards of the remaining cards, times the ways to deal
+// holeCards to each of opponents unknown hands in seat order. It is a float64 because
+// it overflows integers for many opponents.
+func ExactCombinations(remaining, boardCards, holeCards, opponents int) float64 {
+	n := binomial(remaining, boardCards)
+	remaining -= boardCards
+	for i := 0; i < opponents; i++ {
+		n *= binomial(remaining, holeCards)
+		remaining -= holeCards
+	}
+	return n
+}
+
+func binomial(n, k int) float64 {
+	if k < 0 || k > n {
+		return 0
+	}
+	r := 1.0
+	for i := 0; i < k; i++ {
+		r = r * float64(n-i) / float64(i+1)
+	}
+	return r
+}
+
+// ExactWinProbability is Simulator.WinProbability computed exactly: it visits every
+// completion of the board and every holding of the numPlayers-1 opponents (see
+// ExactCombinations). The estimates have no error, Sims counts the runouts visited and
+// HandTypes is filled in when handTypes is set, as Simulator.HandTypes does. It stops
+// with ctx.Err() when ctx is done first.
+func ExactWinProbability(ctx context.Context, game hand.Variant, hole, community []hand.Card, numPlayers int, handTypes bool) (Result, error) {
+	e, err := newEnumerator(ctx, game, [][]hand.Card{hole}, community, numPlayers-1, len(hole))
+	if err != nil {
+		return Result{}, err
+	}
+	rules := game.Rules()
+	var ours hand.Strength
+	var wins, ties, equity float64
+	ourType := hand.HighCard
+	var types, turnTypes []float64 // turnTypes per runout of the board
+	if handTypes {
+		types = make([]float64, handTypeSlots)
+		if len(community) == 3 {
+			turnTypes = make([]float64, numHandTypes)
+		}
+	}
+	e.onBoard = func() {
+		ours = game.Evaluate(hole, e.board)
+		ourType = rules.Type(ours)
+		if turnTypes != nil {
+			clear(turnTypes)
+			addTurnTypes(turnTypes, game, hole, e.board)
+		}
+	}
+	e.visit = func() {
+		for i, f := range turnTypes {
+			types[i] += f
+		}
+		tiedWith := 0
+		for _, opp := range e.unknown {
+			ov := game.Evaluate(opp, e.board)
+			if ours < ov {
+				if types != nil {
+					addHandType(types, -1, ourType, 0)
+				}
+				return
+			}
+			if ours == ov {
+				tiedWith++
+			}
+		}
+		if tiedWith > 0 {
+			ties++
+		} else {
+			wins++
+		}
+		share := 1 / float64(tiedWith+1)
+		equity += share
+		if types != nil {
+			addHandType(types, -1, ourType, share)
+		}
+	}
+	if err := e.run(); err != nil {
+		return Result{}, err
+	}
+	n := float64(e.visits)
+	res := Result{
+		Players: []PlayerEquity{{Win: exactEstimate(wins / n), Tie: exactEstimate(ties / n), Equity: exactEstimate(equity / n)}},
+		Sims:    e.visits,
+		Stop:    StopCompleted,
+	}
+	if handTypes {
+		res.HandTypes = handTypeStats(types, e.visits, 1, len(community) == 3)
+	}
+	return res, nil
+}
+
+// ExactWinProbabilityMulti is Simulator.WinProbabilityMulti computed exactly over every
+// completion of the board, with HandTypes when handTypes is set. It stops with ctx.Err()
+// when ctx is done first.
+func ExactWinProbabilityMulti(ctx context.Context, game hand.Variant, holes [][]hand.Card, community []hand.Card, handTypes bool) (Result, error) {
+	e, err := newEnumerator(ctx, game, holes, community, 0, 0)
+	if err != nil {
+		return Result{}, err
+	}
+	n := len(holes)
+	s := Simulator{HandTypes: handTypes}
+	tally := make([]float64, s.showdownWidth(n))
+	types := tally[n*showdownSlots:]
+	vals := make([]hand.Strength, n)
+	e.visit = func() {
+		for i, h := range holes {
+			vals[i] = game.Evaluate(h, e.board)
+			if handTypes && len(community) == 3 {
+				addTurnTypes(types[i*handTypeSlots:], game, h, e.board)
+			}
+		}
+		s.tallyPlayers(game.Rules(), nil, vals, tally)
+	}
+	if err := e.run(); err != nil {
+		return Result{}, err
+	}
+	visits := float64(e.visits)
+	res := Result{Players: make([]PlayerEquity, n), Sims: e.visits, Stop: StopCompleted}
+	if handTypes {
+		res.HandTypes = handTypeStats(types, e.visits, n, len(community) == 3)
+	}
+	for i := range res.Players {
+		t := tally[i*showdownSlots:]
+		res.Players[i] = PlayerEquity{Win: exactEstimate(t[0] / visits), Tie: exactEstimate(t[1] / visits), Equity: exactEstimate(t[2] / visits)}
+	}
+	return res, nil
+}
+
+// addTurnTypes adds the hand types hole makes on the turn of a board that started on the
+// flop to t, a handTypeSlots tally. Enumeration visits each pair of turn and river cards
+// once, so either may have come first: each turn counts half.
+func addTurnTypes(t []float64, game hand.Variant, hole, board []hand.Card) {
+	other := [4]hand.Card{board[0], board[1], board[2], board[4]}
+	rules := game.Rules()
+	t[rules.Type(game.Evaluate(hole, board[:4]))] += 0.5
+	t[rules.Type(game.Evaluate(hole, other[:]))] += 0.5
+}
+
+// ExactWinProbabilityHiLo is WinProbabilityHiLo computed exactly, like ExactWinProbability.
+func ExactWinProbabilityHiLo(ctx context.Context, game hand.Variant, hole, community []hand.Card, numPlayers int) (HiLoResult, error) {
+	e, err := newEnumerator(ctx, game, [][]hand.Card{hole}, community, numPlayers-1, len(hole))
+	if err != nil {
+		return HiLoResult{}, err
+	}
+	highs := make([]hand.Strength, numPlayers)
+	lows := make([]hand.Low, numPlayers)
+	shares := make([]hand.PotShare, numPlayers)
+	tally := make([]float64, hiLoSlots)
+	total := 0
+	e.onBoard = func() {
+		highs[0] = game.Evaluate(hole, e.board)
+		lows[0] = game.EvaluateLow(hole, e.board)
+	}
+	e.visit = func() {
+		for o, opp := range e.unknown {
+			highs[o+1] = game.Evaluate(opp, e.board)
+			lows[o+1] = game.EvaluateLow(opp, e.board)
+		}
+		hand.Showdown(highs, lows, shares)
+		addShare(tally, shares[0])
+		total++
+	}
+	if err := e.run(); err != nil {
+		return HiLoResult{}, err
+	}
+	return exactHiLo(tally, total), nil
+}
+
+// ExactWinProbabilityMultiHiLo is WinProbabilityMultiHiLo computed exactly over every
+// completion of the board.
+func ExactWinProbabilityMultiHiLo(ctx context.Context, game hand.Variant, holes [][]hand.Card, community []hand.Card) ([]HiLoResult, error) {
+	e, err := newEnumerator(ctx, game, holes, community, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	n := len(holes)
+	highs := make([]hand.Strength, n)
+	lows := make([]hand.Low, n)
+	shares := make([]hand.PotShare, n)
+	tally := make([]float64, n*hiLoSlots)
+	total := 0
+	e.visit = func() {
+		for i, h := range holes {
+			highs[i] = game.Evaluate(h, e.board)
+			lows[i] = game.EvaluateLow(h, e.board)
+		}
+		hand.Showdown(highs, lows, shares)
+		for i, s := range shares {
+			addShare(tally[i*hiLoSlots:], s)
+		}
+		total++
+	}
+	if err := e.run(); err != nil {
+		return nil, err
+	}
+	res := make([]HiLoResult, n)
+	for i := range res {
+		res[i] = exactHiLo(tally[i*hiLoSlots:], total)
+	}
+	return res, nil
+}
+
+// enumerator visits every completion of a board and every deal of unknown hands from
+// the live cards. onBoard (optional) runs once per completed board, visit once per
+// deal of the unknown hands on it.
+type enumerator struct {
+	ctx        context.Context
+	live       []hand.Card
+	board      []hand.Card // the community cards, completed in place
+	nCommunity int
+	unknown    [][]hand.Card // unknown hands, dealt in place
+	onBoard    func()
+	visit      func()
+	visits     int
+	err        error
+}
+
+// newEnumerator checks the known hands and board of game and sets up the deal of
+// `unknown` further hands of holeCards cards each.
+func newEnumerator(ctx context.Context, game hand.Variant, known [][]hand.Card, community []hand.Card, unknown, holeCards int) (*enumerator, error) {
+	if len(known)+unknown < 2 || len(community) > 5 || len(community) == 1 || len(community) == 2 {
+		return nil, ErrInvalidInput
+	}
+	dead := hand.NewCardSet(community...)
+	nKnown := len(community)
+	for _, h := range known {
+		if !game.ValidHoleCount(len(h)) {
+			return nil, ErrInvalidInput
+		}
+		dead = dead.Union(hand.NewCardSet(h...))
+		nKnown += len(h)
+	}
+	if dead.Count() != nKnown {
+		return nil, ErrInvalidInput // duplicate cards
+	}
+	live := (game.Rules().Cards() &^ dead).Cards()
+	if len(live) < 5-len(community)+unknown*holeCards {
+		return nil, ErrInvalidInput
+	}
+	e := &enumerator{
+		ctx:        ctx,
+		live:       live,
+		board:      make([]hand.Card, 5),
+		nCommunity: len(community),
+		unknown:    make([][]hand.Card, unknown),
+	}
+	copy(e.board, community)
+	for i := range e.unknown {
+		e.unknown[i] = make([]hand.Card, holeCards)
+	}
+	return e, nil
+}
+
+// run enumerates everything and returns ctx.Err() if ctx was done before the end.
+func (e *enumerator) run() error {
+	e.chooseBoard(e.nCommunity, 0, 0)
+	return e.err
+}
+
+// chooseBoard fills board positions pos.. with live cards from index start on.
+func (e *enumerator) chooseBoard(pos, start int, used hand.CardSet) {
+	if e.err != nil {
+		return
+	}
+	if pos == len(e.board) {
+		if e.onBoard != nil {
+			e.onBoard()
+		}
+		e.deal(0, 0, 0, used)
+		return
+	}
+	for i := start; i <= len(e.live)-(len(e.board)-pos); i++ {
+		e.board[pos] = e.live[i]
+		e.chooseBoard(pos+1, i+1, used.Add(e.live[i]))
+	}
+}
+
+// deal fills card j of unknown hand h with unused live cards from index start on.
+func (e *enumerator) deal(h, j, start int, used hand.CardSet) {
+	if e.err != nil {
+		return
+	}
+	if h == len(e.unknown) {
+		e.visit()
+		e.visits++
+		if e.visits&(1<<12-1) == 0 {
+			e.err = e.ctx.Err()
+		}
+		return
+	}
+	if j == len(e.unknown[h]) {
+		e.deal(h+1, 0, 0, used)
+		return
+	}
+	for i := start; i < len(e.live); i++ {
+		c := e.live[i]
+		if used.Contains(c) {
+			continue
+		}
+		e.unknown[h][j] = c
+		e.deal(h, j+1, i+1, used.Add(c))
+	}
+}
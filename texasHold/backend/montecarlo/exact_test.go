@@ -0,0 +1,117 @@
+package montecarlo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"texashold-backend/hand"
+)
+
+func TestExactCombinations(t *testing.T) {
+	tests := []struct {
+		remaining, board, hole, opps int
+		want                         float64
+	}{
+		{48, 5, 0, 0, 1712304}, // heads-up preflop, both hands known
+		{45, 2, 0, 0, 990},     // flop, two known hands
+		{46, 1, 2, 1, 46 * 990},
+		{45, 0, 2, 2, 990 * 903},
+		{3, 5, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := ExactCombinations(tt.remaining, tt.board, tt.hole, tt.opps); got != tt.want {
+			t.Errorf("ExactCombinations(%d, %d, %d, %d) = %v, want %v", tt.remaining, tt.board, tt.hole, tt.opps, got, tt.want)
+		}
+	}
+}
+
+func TestExactWinProbabilityMulti(t *testing.T) {
+	ctx := context.Background()
+	// On the river the result is certain.
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "SQ SJ")}
+	res, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, cards(t, "HQ HJ HT D2 C3"), false)
+	if err != nil || res.Players[0].Win.Value != 1 || res.Players[1].Win.Value != 0 || res.Players[0].Tie.Value != 0 || res.Players[1].Tie.Value != 0 {
+		t.Errorf("river royal flush: %+v err %v", res.Players, err)
+	}
+
+	// On the turn QJ has 13 outs among 44 rivers: 3 queens, 3 jacks, and 3 kings and
+	// 4 eights for a straight.
+	res, err = ExactWinProbabilityMulti(ctx, hand.Holdem, holes, cards(t, "DT C9 D4 S2"), false)
+	if err != nil || math.Abs(res.Players[1].Win.Value-13.0/44) > 1e-12 || res.Players[0].Tie.Value != 0 || res.Players[1].Tie.Value != 0 {
+		t.Errorf("turn: %+v err %v", res.Players, err)
+	}
+
+	// The flop agrees with sampling, ties included, and heads-up both players tie together.
+	holes = [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9")}
+	board := cards(t, "H9 H5 C2")
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled, sampledTie := WinProbabilityMulti(hand.Holdem, holes, board, 50000)
+	for i, p := range exact.Players {
+		if math.Abs(p.Win.Value-sampled[i]) > 0.02 || math.Abs(p.Tie.Value-sampledTie[i]) > 0.01 {
+			t.Errorf("player %d: exact %v tie %v, sampled %v tie %v", i, p.Win.Value, p.Tie.Value, sampled[i], sampledTie[i])
+		}
+	}
+	a, b := exact.Players[0], exact.Players[1]
+	if math.Abs(a.Win.Value+b.Win.Value+a.Tie.Value-1) > 1e-9 || a.Tie.Value != b.Tie.Value {
+		t.Errorf("ties: %+v", exact.Players)
+	}
+
+	if _, err := ExactWinProbabilityMulti(ctx, hand.Holdem, [][]hand.Card{cards(t, "HA HK"), cards(t, "HA SK")}, nil, false); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("duplicate cards: err %v", err)
+	}
+}
+
+func TestExactPreflopHeadsUp(t *testing.T) {
+	if testing.Short() {
+		t.Skip("enumerates 1.7M boards")
+	}
+	// Aces against kings of other suits, a well-known matchup.
+	holes := [][]hand.Card{cards(t, "HA SA"), cards(t, "DK CK")}
+	res, err := ExactWinProbabilityMulti(context.Background(), hand.Holdem, holes, nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.Players[0]; p.Win.Value < 0.81 || p.Win.Value > 0.83 || p.Tie.Value <= 0 || p.Tie.Value > 0.01 {
+		t.Errorf("AA vs KK: %+v", res.Players)
+	}
+}
+
+func TestExactWinProbability(t *testing.T) {
+	ctx := context.Background()
+	// The nuts on the river against two random hands can only tie with another royal.
+	res, err := ExactWinProbability(ctx, hand.Holdem, cards(t, "HA HK"), cards(t, "HQ HJ HT D2 C3"), 3, false)
+	if p := res.Players[0]; err != nil || p.Win.Value != 1 || p.Tie.Value != 0 || p.Equity.Value != 1 {
+		t.Errorf("royal flush: %+v err %v", res, err)
+	}
+	// One random opponent on the river holds one of 990 hands.
+	res, err = ExactWinProbability(ctx, hand.Holdem, cards(t, "S2 C7"), cards(t, "DA DK H9 H5 C3"), 2, false)
+	if err != nil || res.Sims != 990 {
+		t.Fatalf("river: %d runouts, err %v", res.Sims, err)
+	}
+	p := res.Players[0]
+	if math.Abs(p.Equity.Value-p.Win.Value-p.Tie.Value/2) > 1e-12 {
+		t.Errorf("heads-up equity %v, want win + tie/2 of %+v", p.Equity.Value, p)
+	}
+	for _, p := range []float64{p.Win.Value, p.Tie.Value} {
+		if n := p * 990; math.Abs(n-math.Round(n)) > 1e-9 {
+			t.Errorf("%v is not a multiple of 1/990", p)
+		}
+	}
+
+	// The nut low against a hand without low cards takes the low half on all 40 rivers.
+	hiLo, err := ExactWinProbabilityMultiHiLo(ctx, hand.OmahaHiLo, [][]hand.Card{cards(t, "HA H2 S3 SK"), cards(t, "SQ DQ HJ DJ")}, cards(t, "D4 C5 D8 CK"))
+	if err != nil || hiLo[0].LowHalf != 1 || math.Abs(hiLo[0].Equity+hiLo[1].Equity-1) > 1e-12 {
+		t.Errorf("hi/lo: %+v err %v", hiLo, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := ExactWinProbability(cancelled, hand.Holdem, cards(t, "HA HK"), nil, 3, false); !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled: err %v", err)
+	}
+}
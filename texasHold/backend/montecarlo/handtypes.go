@@ -0,0 +1,105 @@
+package montecarlo
+
+import "texashold-backend/hand"
+
+// numHandTypes is the number of hand.HandType values, Five of a Kind included.
+const numHandTypes = int(hand.FiveOfAKind) + 1
+
+// handTypeSlots is the width of one player's made-hand tally: for each hand.HandType the
+// sims it was made at the turn, the sims it was made at the river, and the pot won with it.
+const handTypeSlots = 3 * numHandTypes
+
+// HandTypeStats is one player's made-hand distribution. Each slice is indexed by
+// hand.HandType.
+type HandTypeStats struct {
+	Turn   []float64 // fraction of sims holding each type on the turn; nil unless the board started on the flop
+	River  []float64 // fraction of sims finishing with each type
+	Equity []float64 // average pot share won with each type; the shares sum to the player's equity
+}
+
+// WinRate returns the average share of the pot won when finishing with t, or 0 if t was
+// never made.
+func (h HandTypeStats) WinRate(t hand.HandType) float64 {
+	if h.River[t] == 0 {
+		return 0
+	}
+	return h.Equity[t] / h.River[t]
+}
+
+// addHandType adds one sim's made hands to t, a player's handTypeSlots tally: the type
+// held on the turn (negative when not tracked), the type at the river and the pot share won.
+func addHandType(t []float64, turn, river hand.HandType, share float64) {
+	if turn >= 0 {
+		t[turn]++
+	}
+	t[numHandTypes+int(river)]++
+	t[2*numHandTypes+int(river)] += share
+}
+
+// tallyHandTypes adds every player's made hands to tally, handTypeSlots per player: the
+// types of the turn strengths (nil when not tracked) and of the river strengths vals,
+// and the pot shares won at the showdown of vals.
+func tallyHandTypes(rules *hand.Ruleset, turn, vals []hand.Strength, tally []float64) {
+	best, nBest := bestOf(vals)
+	for i, v := range vals {
+		share, turnType := 0.0, hand.HandType(-1)
+		if v == best {
+			share = 1 / float64(nBest)
+		}
+		if turn != nil {
+			turnType = rules.Type(turn[i])
+		}
+		addHandType(tally[i*handTypeSlots:], turnType, rules.Type(v), share)
+	}
+}
+
+// handTypeStats turns n players' hand-type tallies in sums into fractions of sims.
+// The Turn fractions are only reported when the turn was tracked.
+func handTypeStats(sums []float64, sims, n int, turn bool) []HandTypeStats {
+	out := make([]HandTypeStats, n)
+	frac := func(t []float64) []float64 {
+		f := make([]float64, numHandTypes)
+		for i := range f {
+			f[i] = t[i] / float64(max(sims, 1))
+		}
+		return f
+	}
+	for i := range out {
+		t := sums[i*handTypeSlots:]
+		out[i] = HandTypeStats{River: frac(t[numHandTypes:]), Equity: frac(t[2*numHandTypes:])}
+		if turn {
+			out[i].Turn = frac(t)
+		}
+	}
+	return out
+}
+
+// showdownWidth returns the tally width of a showdown between n players: showdownSlots
+// each, and handTypeSlots each more when s collects hand types.
+func (s Simulator) showdownWidth(n int) int {
+	if s.HandTypes {
+		return n * (showdownSlots + handTypeSlots)
+	}
+	return n * showdownSlots
+}
+
+// tallyPlayers adds a showdown to a tally of width s.showdownWidth, the made hands
+// included when s collects them (turn is nil unless the board started on the flop).
+func (s Simulator) tallyPlayers(rules *hand.Ruleset, turn, vals []hand.Strength, tally []float64) {
+	tallyShowdown(vals, tally)
+	if s.HandTypes {
+		tallyHandTypes(rules, turn, vals, tally[len(vals)*showdownSlots:])
+	}
+}
+
+// playersResult returns the function turning a tally of s.showdownWidth(n) into a
+// Result, for a board starting with nCommunity cards.
+func (s Simulator) playersResult(n, nCommunity int) func(sums []float64, sims int) Result {
+	return func(sums []float64, sims int) Result {
+		res := showdownResult(sums, sims, n)
+		if s.HandTypes {
+			res.HandTypes = handTypeStats(sums[n*showdownSlots:], sims, n, nCommunity == 3)
+		}
+		return res
+	}
+}
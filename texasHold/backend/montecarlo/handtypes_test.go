@@ -0,0 +1,90 @@
+package montecarlo
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+
+	"texashold-backend/hand"
+)
+
+func TestHandTypes(t *testing.T) {
+	ctx := context.Background()
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9"), cards(t, "C8 C7")}
+	board := cards(t, "H9 H5 C6")
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled := Simulator{Seed: 2, HandTypes: true}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, 50000)
+	for _, res := range []Result{exact, sampled} {
+		if len(res.HandTypes) != 3 {
+			t.Fatalf("%d hand-type distributions", len(res.HandTypes))
+		}
+		for i, h := range res.HandTypes {
+			var turn, river, equity float64
+			for ht := range h.River {
+				turn += h.Turn[ht]
+				river += h.River[ht]
+				equity += h.Equity[ht]
+			}
+			if math.Abs(turn-1) > 1e-9 || math.Abs(river-1) > 1e-9 || math.Abs(equity-res.Players[i].Equity.Value) > 1e-9 {
+				t.Errorf("player %d: turn %v river %v equity %v of %v", i, turn, river, equity, res.Players[i].Equity.Value)
+			}
+		}
+	}
+	// Sets hold up on the turn at least as often as at the river; a flop set never ends
+	// below three of a kind.
+	set := exact.HandTypes[1]
+	if set.River[hand.OnePair] != 0 || set.Turn[hand.ThreeOfAKind] < set.River[hand.ThreeOfAKind] {
+		t.Errorf("set of nines: %+v", set)
+	}
+	// Sampling agrees with enumeration.
+	for i, h := range sampled.HandTypes {
+		want := exact.HandTypes[i]
+		for ht := range h.River {
+			if math.Abs(h.River[ht]-want.River[ht]) > 0.01 || math.Abs(h.Turn[ht]-want.Turn[ht]) > 0.01 {
+				t.Errorf("player %d %s: river %v turn %v, exact %v %v", i, hand.HandType(ht), h.River[ht], h.Turn[ht], want.River[ht], want.Turn[ht])
+			}
+		}
+	}
+	if wr := exact.HandTypes[0].WinRate(hand.Flush); wr < 0.5 || wr > 1 {
+		t.Errorf("nut flush win rate %v", wr)
+	}
+	// Unasked, exact enumeration skips them, with the same equities.
+	plain, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, false)
+	if err != nil || plain.HandTypes != nil || !reflect.DeepEqual(plain.Players, exact.Players) {
+		t.Errorf("without hand types: %+v, %v", plain, err)
+	}
+	single, err := ExactWinProbability(ctx, hand.Holdem, holes[0], board, 2, false)
+	if err != nil || single.HandTypes != nil {
+		t.Errorf("single without hand types: %+v, %v", single.HandTypes, err)
+	}
+
+	// Our hand alone against a random opponent, from the turn: no turn distribution.
+	turnBoard := cards(t, "H9 H5 C6 D2")
+	exactSingle, err := ExactWinProbability(ctx, hand.Holdem, holes[0], turnBoard, 2, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := Simulator{Seed: 2, HandTypes: true}.WinProbability(ctx, hand.Holdem, hand.Wilds{}, holes[0], turnBoard, 2, 20000)
+	if len(res.HandTypes) != 1 || res.HandTypes[0].Turn != nil || exactSingle.HandTypes[0].Turn != nil {
+		t.Fatalf("single: %+v", res.HandTypes)
+	}
+	if f, want := res.HandTypes[0].River[hand.Flush], exactSingle.HandTypes[0].River[hand.Flush]; math.Abs(want-9.0/46) > 1e-9 || math.Abs(f-want) > 0.015 {
+		t.Errorf("flush on the river %v, exact %v", f, want)
+	}
+	var equity float64
+	for _, e := range res.HandTypes[0].Equity {
+		equity += e
+	}
+	if math.Abs(equity-res.Players[0].Equity.Value) > 1e-9 {
+		t.Errorf("hand-type equity %v, equity %v", equity, res.Players[0].Equity.Value)
+	}
+
+	// Without HandTypes nothing is collected.
+	if res := (Simulator{Seed: 2}).WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, 100); res.HandTypes != nil {
+		t.Errorf("collected %+v", res.HandTypes)
+	}
+}
@@ -0,0 +1,163 @@
+package montecarlo
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"texashold-backend/hand"
+)
+
+// HiLoResult is one player's average outcome in a hi/lo split-pot game.
+type HiLoResult struct {
+	Scoop    float64 // fraction of sims where the player won the whole pot alone
+	Split    float64 // fraction of sims where the player won part of the pot
+	HighHalf float64 // average share of the high half won (whole pot when nobody has a low)
+	LowHalf  float64 // average share of the low half won
+	Equity   float64 // average fraction of the pot won
+
+	// Stats has Scoop, Split and Equity as Win, Tie and Equity with their confidence
+	// intervals.
+	Stats PlayerEquity
+}
+
+// hiLoSlots is the width of one player's HiLoResult in a tally: its fields in order,
+// then the sum of the squared equity.
+const hiLoSlots = 6
+
+// addShare accumulates one showdown share into t, a player's hiLoSlots tally.
+func addShare(t []float64, s hand.PotShare) {
+	if s.Scoop {
+		t[0]++
+	} else if s.Total > 0 {
+		t[1]++
+	}
+	t[2] += s.High
+	t[3] += s.Low
+	t[4] += s.Total
+	t[5] += s.Total * s.Total
+}
+
+// hiLoResult turns a player's accumulated tally into fractions of nSims.
+func hiLoResult(t []float64, nSims int) HiLoResult {
+	n := float64(nSims)
+	return HiLoResult{
+		Scoop: t[0] / n, Split: t[1] / n, HighHalf: t[2] / n, LowHalf: t[3] / n, Equity: t[4] / n,
+		Stats: PlayerEquity{Win: estimate(t[0], t[0], nSims), Tie: estimate(t[1], t[1], nSims), Equity: estimate(t[4], t[5], nSims)},
+	}
+}
+
+// exactHiLo is hiLoResult for a tally of every runout: its estimates have no error.
+func exactHiLo(t []float64, runouts int) HiLoResult {
+	r := hiLoResult(t, runouts)
+	r.Stats = PlayerEquity{Win: exactEstimate(r.Scoop), Tie: exactEstimate(r.Split), Equity: exactEstimate(r.Equity)}
+	return r
+}
+
+// WinProbabilityHiLo is WinProbability for hi/lo games: each sim's pot is split between
+// the best high and the best qualifying low, and the result reports our scoop, split and
+// half-pot shares against numPlayers-1 random opponents.
+func WinProbabilityHiLo(game hand.Variant, hole []hand.Card, community []hand.Card, numPlayers, nSims int) HiLoResult {
+	res, _, _ := defaultSimulator().WinProbabilityHiLo(context.Background(), game, hole, community, numPlayers, nSims)
+	return res
+}
+
+// WinProbabilityHiLo is the package's WinProbabilityHiLo run by s. It stops early when
+// ctx is done and returns how many simulations ran and why it stopped.
+func (s Simulator) WinProbabilityHiLo(ctx context.Context, game hand.Variant, hole []hand.Card, community []hand.Card, numPlayers, nSims int) (res HiLoResult, sims int, stop StopReason) {
+	if numPlayers < 2 || nSims <= 0 || !game.ValidHoleCount(len(hole)) {
+		return res, 0, StopCompleted
+	}
+	dead := hand.NewCardSet(hole...).Union(hand.NewCardSet(community...))
+	if dead.Count() != len(hole)+len(community) {
+		return res, 0, StopCompleted // duplicate cards
+	}
+	if game.Rules().NewDeck(dead).Remaining() < 5-len(community)+len(hole)*(numPlayers-1) {
+		return res, 0, StopCompleted
+	}
+	sums, sims, stop := s.run(ctx, nSims, hiLoSlots, func() trial {
+		deck := game.Rules().NewDeck(dead)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		oppHole := make([]hand.Card, len(hole))
+		highs := make([]hand.Strength, numPlayers)
+		lows := make([]hand.Low, numPlayers)
+		shares := make([]hand.PotShare, numPlayers)
+		return func(r *rand.Rand, tally []float64) {
+			deck.Shuffle(r)
+			for i := len(community); i < 5; i++ {
+				board[i] = deck.Deal()
+			}
+			highs[0] = game.Evaluate(hole, board)
+			lows[0] = game.EvaluateLow(hole, board)
+			for o := 1; o < numPlayers; o++ {
+				for i := range oppHole {
+					oppHole[i] = deck.Deal()
+				}
+				highs[o] = game.Evaluate(oppHole, board)
+				lows[o] = game.EvaluateLow(oppHole, board)
+			}
+			hand.Showdown(highs, lows, shares)
+			addShare(tally, shares[0])
+		}
+	}, nil)
+	return hiLoResult(sums, sims), sims, stop
+}
+
+// WinProbabilityMultiHiLo is WinProbabilityMulti for hi/lo games: all players' hole cards
+// are fixed and the result reports each player's scoop, split and half-pot shares.
+// The players' equities sum to 1.0.
+func WinProbabilityMultiHiLo(game hand.Variant, holes [][]hand.Card, community []hand.Card, nSims int) []HiLoResult {
+	res, _, _ := defaultSimulator().WinProbabilityMultiHiLo(context.Background(), game, holes, community, nSims)
+	return res
+}
+
+// WinProbabilityMultiHiLo is the package's WinProbabilityMultiHiLo run by s. It stops
+// early when ctx is done and returns how many simulations ran and why it stopped.
+func (s Simulator) WinProbabilityMultiHiLo(ctx context.Context, game hand.Variant, holes [][]hand.Card, community []hand.Card, nSims int) (res []HiLoResult, sims int, stop StopReason) {
+	nPlayers := len(holes)
+	if nPlayers < 2 || nSims <= 0 {
+		return nil, 0, StopCompleted
+	}
+	dead := hand.NewCardSet(community...)
+	nKnown := len(community)
+	for _, h := range holes {
+		if !game.ValidHoleCount(len(h)) {
+			return nil, 0, StopCompleted
+		}
+		dead = dead.Union(hand.NewCardSet(h...))
+		nKnown += len(h)
+	}
+	if dead.Count() != nKnown {
+		return nil, 0, StopCompleted // duplicate cards
+	}
+	if game.Rules().NewDeck(dead).Remaining() < 5-len(community) {
+		return nil, 0, StopCompleted
+	}
+	sums, sims, stop := s.run(ctx, nSims, nPlayers*hiLoSlots, func() trial {
+		deck := game.Rules().NewDeck(dead)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		highs := make([]hand.Strength, nPlayers)
+		lows := make([]hand.Low, nPlayers)
+		shares := make([]hand.PotShare, nPlayers)
+		return func(r *rand.Rand, tally []float64) {
+			deck.Shuffle(r)
+			for i := len(community); i < 5; i++ {
+				board[i] = deck.Deal()
+			}
+			for i := 0; i < nPlayers; i++ {
+				highs[i] = game.Evaluate(holes[i], board)
+				lows[i] = game.EvaluateLow(holes[i], board)
+			}
+			hand.Showdown(highs, lows, shares)
+			for i, s := range shares {
+				addShare(tally[i*hiLoSlots:], s)
+			}
+		}
+	}, nil)
+	res = make([]HiLoResult, nPlayers)
+	for i := range res {
+		res[i] = hiLoResult(sums[i*hiLoSlots:], sims)
+	}
+	return res, sims, stop
+}
@@ -1,195 +1,201 @@
 package montecarlo
 
 import (
-	"math/rand"
+	"context"
+	"math/rand/v2"
+
 	"texashold-backend/hand"
 )
 
-// WinProbability runs nSims Monte Carlo simulations. Given our 2 hole cards and
-// 0/3/4/5 community cards, numPlayers-1 opponents get random hands. Returns
-// win fraction (outright wins) and tie fraction (sims where we tie all opponents).
-// Equity = winFrac + 0.5*tieFrac.
-func WinProbability(hole []hand.Card, community []hand.Card, numPlayers, nSims int) (winFrac, tieFrac float64) {
-	if numPlayers < 2 || nSims <= 0 {
+// WinProbability runs nSims Monte Carlo simulations of game. Given our hole cards
+// (2 for Hold'em, 4 or 5 for Omaha) and 0/3/4/5 community cards, numPlayers-1 opponents
+// get random hands with as many hole cards as ours. Returns win fraction (outright wins)
+// and tie fraction (sims where we share the best hand with one or more opponents).
+// Heads-up, equity = winFrac + 0.5*tieFrac; Simulator.WinProbability reports it in general.
+func WinProbability(game hand.Variant, hole []hand.Card, community []hand.Card, numPlayers, nSims int) (winFrac, tieFrac float64) {
+	return WinProbabilityWild(game, hand.Wilds{}, hole, community, numPlayers, nSims)
+}
+
+// WinProbabilityWild is WinProbability with wild cards: the deck holds w.Jokers jokers
+// and every hand is evaluated with w (see hand.Wilds).
+func WinProbabilityWild(game hand.Variant, w hand.Wilds, hole []hand.Card, community []hand.Card, numPlayers, nSims int) (winFrac, tieFrac float64) {
+	res := defaultSimulator().WinProbability(context.Background(), game, w, hole, community, numPlayers, nSims)
+	if len(res.Players) == 0 {
 		return 0, 0
 	}
-	if len(hole) != 2 {
-		return 0, 0
+	return res.Players[0].Win.Value, res.Players[0].Tie.Value
+}
+
+// WinProbability is the package's WinProbabilityWild run by s, reporting our win, tie
+// and equity with their confidence intervals in a Result with one player. It runs up
+// to nSims simulations, fewer when ctx is done or s.TargetMargin is reached;
+// StratifiedSampling and AntitheticSampling round nSims up to whole batches. The Result
+// has no players for invalid input.
+func (s Simulator) WinProbability(ctx context.Context, game hand.Variant, w hand.Wilds, hole []hand.Card, community []hand.Card, numPlayers, nSims int) Result {
+	if numPlayers < 2 || nSims <= 0 {
+		return Result{}
+	}
+	if !game.ValidHoleCount(len(hole)) {
+		return Result{}
+	}
+	dead := hand.NewCardSet(hole...).Union(hand.NewCardSet(community...))
+	if dead.Count() != len(hole)+len(community) {
+		return Result{} // duplicate cards
+	}
+	if game.Rules().NewWildDeck(dead, w).Remaining() < 5-len(community)+len(hole)*(numPlayers-1) {
+		return Result{}
+	}
+	if (s.Sampling == StratifiedSampling || s.Sampling == AntitheticSampling) && len(community) < 5 {
+		return s.sampled(ctx, game, w, [][]hand.Card{hole}, numPlayers-1, community, dead, nSims)
 	}
-	wins := 0
-	ties := 0
-	deck := fullDeck()
-	used := make(map[hand.Card]bool)
-	for _, c := range hole {
-		used[c] = true
+	// tally: wins, ties, equity, equity squared, then our made hands
+	turn := s.HandTypes && len(community) == 3
+	width := showdownSlots
+	if s.HandTypes {
+		width += handTypeSlots
 	}
-	for _, c := range community {
-		used[c] = true
+	result := func(sums []float64, sims int) Result {
+		res := Result{Players: []PlayerEquity{{
+			Win:    estimate(sums[0], sums[0], sims),
+			Tie:    estimate(sums[1], sums[1], sims),
+			Equity: estimate(sums[2], sums[3], sims),
+		}}, Sims: sims}
+		res.ESS, res.Variance = float64(sims), res.equityVariance()
+		if s.HandTypes {
+			res.HandTypes = handTypeStats(sums[showdownSlots:], sims, 1, turn)
+		}
+		return res
 	}
-	for sim := 0; sim < nSims; sim++ {
-		// Shuffle and deal remaining community + opponent hole cards
-		remaining := removeUsed(deck, used)
-		rand.Shuffle(len(remaining), func(i, j int) { remaining[i], remaining[j] = remaining[j], remaining[i] })
-		// Complete community to 5
-		commCount := len(community)
-		var fullCommunity []hand.Card
-		if commCount < 5 {
-			fullCommunity = make([]hand.Card, 5)
-			copy(fullCommunity, community)
-			for i := commCount; i < 5; i++ {
-				fullCommunity[i] = remaining[i-commCount]
+	rules := game.Rules()
+	sums, sims, stop := s.run(ctx, nSims, width, func() trial {
+		deck := rules.NewWildDeck(dead, w)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		oppHole := make([]hand.Card, len(hole))
+		return func(r *rand.Rand, tally []float64) {
+			// Shuffle and deal remaining community + opponent hole cards
+			deck.Shuffle(r)
+			for i := len(community); i < 5; i++ {
+				board[i] = deck.Deal()
 			}
-		} else {
-			fullCommunity = community
-		}
-		idx := 5 - commCount
-		// Our best hand
-		ourSeven := append(append([]hand.Card(nil), hole...), fullCommunity...)
-		ourBest, ourVal := hand.BestHand(ourSeven)
-		_ = ourBest
-		// Opponents: each gets 2 hole cards from remaining deck
-		opponentVals := make([]hand.HandValue, numPlayers-1)
-		for o := 0; o < numPlayers-1; o++ {
-			o1 := remaining[idx]
-			idx++
-			o2 := remaining[idx]
-			idx++
-			oppSeven := append(append([]hand.Card{o1, o2}, fullCommunity...))
-			_, oppVal := hand.BestHand(oppSeven)
-			opponentVals[o] = oppVal
-		}
-		// Compare: we need to beat or tie all opponents
-		weWin := true
-		weTie := true
-		for _, ov := range opponentVals {
-			cmp := compareHandValues(ourVal, ov)
-			if cmp < 0 {
-				weWin = false
-				weTie = false
-				break
+			ourVal := game.EvaluateWild(hole, board, w)
+			if s.HandTypes {
+				turnType := hand.HandType(-1)
+				if turn {
+					turnType = rules.Type(game.EvaluateWild(hole, board[:4], w))
+				}
+				// Our share of the pot is added below, once the opponents are known.
+				addHandType(tally[showdownSlots:], turnType, rules.Type(ourVal), 0)
 			}
-			if cmp != 0 {
-				weTie = false
+			// Opponents: each gets random hole cards; we need to beat or tie all of them
+			weWin := true
+			tiedWith := 0
+			for o := 0; o < numPlayers-1; o++ {
+				for i := range oppHole {
+					oppHole[i] = deck.Deal()
+				}
+				ov := game.EvaluateWild(oppHole, board, w)
+				if ourVal < ov {
+					weWin = false
+					break
+				}
+				if ourVal == ov {
+					tiedWith++
+				}
+			}
+			var share float64
+			switch {
+			case !weWin:
+				return
+			case tiedWith > 0:
+				share = 1 / float64(tiedWith+1)
+				tally[1]++
+			default:
+				share = 1
+				tally[0]++
+			}
+			tally[2] += share
+			tally[3] += share * share
+			if s.HandTypes {
+				tally[showdownSlots+2*numHandTypes+int(rules.Type(ourVal))] += share
 			}
 		}
-		if weWin && weTie {
-			ties++
-		} else if weWin {
-			wins++
-		}
-	}
-	n := float64(nSims)
-	return float64(wins) / n, float64(ties) / n
+	}, s.doneAt(result))
+	res := result(sums, sims)
+	res.Stop = stop
+	return res
 }
 
-// WinProbabilityMulti runs one set of nSims with all players' hole cards fixed.
+// WinProbabilityMulti runs one set of nSims of game with all players' hole cards fixed.
 // In each sim the board is completed from the deck (if needed), then we determine
-// winner(s) or tie. Returns per-player win fraction and one tie fraction (same for all).
-// Sum of winFracs + tieFrac = 1.0.
-func WinProbabilityMulti(holes [][]hand.Card, community []hand.Card, nSims int) (winFracs []float64, tieFrac float64) {
+// winner(s) or tie. Returns per-player win fraction (outright wins) and tie fraction
+// (sims where the player shares the best hand with others). The winFracs plus the
+// fraction of split pots sum to 1.0; Simulator.WinProbabilityMulti also reports equity.
+func WinProbabilityMulti(game hand.Variant, holes [][]hand.Card, community []hand.Card, nSims int) (winFracs, tieFracs []float64) {
+	return WinProbabilityMultiWild(game, hand.Wilds{}, holes, community, nSims)
+}
+
+// WinProbabilityMultiWild is WinProbabilityMulti with wild cards w.
+func WinProbabilityMultiWild(game hand.Variant, w hand.Wilds, holes [][]hand.Card, community []hand.Card, nSims int) (winFracs, tieFracs []float64) {
+	res := defaultSimulator().WinProbabilityMulti(context.Background(), game, w, holes, community, nSims)
+	return res.fractions()
+}
+
+// WinProbabilityMulti is the package's WinProbabilityMultiWild run by s, reporting each
+// player's win, tie and equity with their confidence intervals. A k-way split pot counts
+// as a tie for those k players only, and credits each of them 1/k of the pot, so the
+// equities sum to 1.0. It runs up to nSims simulations, fewer when ctx is done or
+// s.TargetMargin is reached; StratifiedSampling and AntitheticSampling round nSims up
+// to whole batches. The Result has no players for invalid input.
+func (s Simulator) WinProbabilityMulti(ctx context.Context, game hand.Variant, w hand.Wilds, holes [][]hand.Card, community []hand.Card, nSims int) Result {
 	nPlayers := len(holes)
 	if nPlayers < 2 || nSims <= 0 {
-		return nil, 0
+		return Result{}
 	}
+	dead := hand.NewCardSet(community...)
+	nKnown := len(community)
 	for _, h := range holes {
-		if len(h) != 2 {
-			return nil, 0
+		if !game.ValidHoleCount(len(h)) {
+			return Result{}
 		}
+		dead = dead.Union(hand.NewCardSet(h...))
+		nKnown += len(h)
 	}
-	deck := fullDeck()
-	used := make(map[hand.Card]bool)
-	for _, h := range holes {
-		for _, c := range h {
-			used[c] = true
-		}
+	if dead.Count() != nKnown {
+		return Result{} // duplicate cards
 	}
-	for _, c := range community {
-		used[c] = true
+	if game.Rules().NewWildDeck(dead, w).Remaining() < 5-len(community) {
+		return Result{}
 	}
-	wins := make([]int, nPlayers)
-	ties := 0
-	for sim := 0; sim < nSims; sim++ {
-		remaining := removeUsed(deck, used)
-		rand.Shuffle(len(remaining), func(i, j int) { remaining[i], remaining[j] = remaining[j], remaining[i] })
-		commCount := len(community)
-		var fullCommunity []hand.Card
-		if commCount < 5 {
-			fullCommunity = make([]hand.Card, 5)
-			copy(fullCommunity, community)
-			for i := commCount; i < 5; i++ {
-				fullCommunity[i] = remaining[i-commCount]
-			}
-		} else {
-			fullCommunity = community
-		}
-		// Best hand value per player
-		vals := make([]hand.HandValue, nPlayers)
-		for i := 0; i < nPlayers; i++ {
-			seven := append(append([]hand.Card(nil), holes[i]...), fullCommunity...)
-			_, vals[i] = hand.BestHand(seven)
-		}
-		// Find winner(s): who has the best hand?
-		bestIdx := 0
-		for i := 1; i < nPlayers; i++ {
-			if compareHandValues(vals[i], vals[bestIdx]) > 0 {
-				bestIdx = i
+	if (s.Sampling == StratifiedSampling || s.Sampling == AntitheticSampling) && len(community) < 5 {
+		return s.sampled(ctx, game, w, holes, 0, community, dead, nSims)
+	}
+	result := s.playersResult(nPlayers, len(community))
+	sums, sims, stop := s.run(ctx, nSims, s.showdownWidth(nPlayers), func() trial {
+		deck := game.Rules().NewWildDeck(dead, w)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		vals := make([]hand.Strength, nPlayers)
+		var turn []hand.Strength
+		if s.HandTypes && len(community) == 3 {
+			turn = make([]hand.Strength, nPlayers)
+		}
+		return func(r *rand.Rand, tally []float64) {
+			deck.Shuffle(r)
+			for i := len(community); i < 5; i++ {
+				board[i] = deck.Deal()
 			}
-		}
-		nBest := 0
-		for i := 0; i < nPlayers; i++ {
-			if compareHandValues(vals[i], vals[bestIdx]) == 0 {
-				nBest++
+			// Best hand value per player
+			for i := 0; i < nPlayers; i++ {
+				vals[i] = game.EvaluateWild(holes[i], board, w)
+				if turn != nil {
+					turn[i] = game.EvaluateWild(holes[i], board[:4], w)
+				}
 			}
+			s.tallyPlayers(game.Rules(), turn, vals, tally)
 		}
-		if nBest > 1 {
-			ties++
-		} else {
-			wins[bestIdx]++
-		}
-	}
-	n := float64(nSims)
-	out := make([]float64, nPlayers)
-	for i := range wins {
-		out[i] = float64(wins[i]) / n
-	}
-	return out, float64(ties) / n
-}
-
-func compareHandValues(a, b hand.HandValue) int {
-	if a.Type != b.Type {
-		if a.Type > b.Type {
-			return 1
-		}
-		return -1
-	}
-	for i := 0; i < len(a.Values) && i < len(b.Values); i++ {
-		if a.Values[i] > b.Values[i] {
-			return 1
-		}
-		if a.Values[i] < b.Values[i] {
-			return -1
-		}
-	}
-	return 0
-}
-
-func fullDeck() []hand.Card {
-	suits := []rune{hand.SuitHeart, hand.SuitSpade, hand.SuitDiamond, hand.SuitClub}
-	var deck []hand.Card
-	for _, s := range suits {
-		for r := hand.Rank2; r <= hand.RankA; r++ {
-			deck = append(deck, hand.Card{Suit: s, Rank: r})
-		}
-	}
-	return deck
-}
-
-func removeUsed(deck []hand.Card, used map[hand.Card]bool) []hand.Card {
-	var out []hand.Card
-	for _, c := range deck {
-		if !used[c] {
-			out = append(out, c)
-		}
-	}
-	return out
+	}, s.doneAt(result))
+	res := result(sums, sims)
+	res.Stop = stop
+	return res
 }
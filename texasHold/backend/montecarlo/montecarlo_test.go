@@ -0,0 +1,49 @@
+package montecarlo
+
+import (
+	"math"
+	"testing"
+
+	"texashold-backend/hand"
+)
+
+func cards(t testing.TB, s string) []hand.Card {
+	t.Helper()
+	c, err := hand.ParseCards(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestStudWinProbability(t *testing.T) {
+	// Complete hands: the result is certain.
+	players := []hand.StudHand{
+		{Down: cards(t, "HA H2"), Up: cards(t, "D3 C4 S5 H9 HK")},
+		{Down: cards(t, "SK SQ"), Up: cards(t, "DK CQ S8 D9 C7")},
+	}
+	if win, tie := StudWinProbability(hand.Razz, players, nil, 100); win[0] != 1 || tie[0] != 0 {
+		t.Errorf("razz wheel vs kings: win %v tie %v", win, tie)
+	}
+	if win, _ := StudWinProbability(hand.SevenCardStud, players, nil, 100); win[0] != 1 {
+		t.Errorf("stud wheel straight vs two pairs: win %v", win)
+	}
+
+	// Rolled-up aces against an unknown hand win most of the time, and dead aces are
+	// never dealt to the opponent.
+	players = []hand.StudHand{
+		{Down: cards(t, "HA SA"), Up: cards(t, "DA")},
+		{Up: cards(t, "C7")},
+	}
+	win, tie := StudWinProbability(hand.SevenCardStud, players, cards(t, "CA"), 20000)
+	if win[0] < 0.8 || tie[0] != tie[1] || math.Abs(win[0]+win[1]+tie[0]-1) > 1e-9 {
+		t.Errorf("rolled-up aces: win %v tie %v", win, tie)
+	}
+	if win, _ := StudWinProbability(hand.SevenCardStud, players, nil, 0); win != nil {
+		t.Errorf("no sims should return nil")
+	}
+	players[1].Up = cards(t, "HA")
+	if win, _ := StudWinProbability(hand.SevenCardStud, players, nil, 10); win != nil {
+		t.Errorf("duplicate cards should return nil")
+	}
+}
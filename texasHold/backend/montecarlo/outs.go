@@ -0,0 +1,143 @@
+package montecarlo
+
+import "texashold-backend/hand"
+
+// OutsResult is what the next card does for each player of a flop or turn situation.
+type OutsResult struct {
+	Players []PlayerOuts
+	Cards   []CardOuts // one per unseen card, in hand.Card.Index order
+}
+
+// PlayerOuts is one player's situation now and the cards that help them.
+type PlayerOuts struct {
+	Type  hand.HandType // hand type now
+	Ahead bool          // holds the best hand alone now
+	// Outs are the next cards that put the player alone ahead while they are behind
+	// or tied now; Tainted are those of them that also improve an opponent's hand type.
+	Outs    []hand.Card
+	Tainted []hand.Card
+	// RunnerRunner (flops only) are the turn and river pairs that put the player alone
+	// ahead at the river although neither card is an out on its own.
+	RunnerRunner []RunnerOut
+}
+
+// RunnerOut is a runner-runner pair of cards and the hand type it makes.
+type RunnerOut struct {
+	Cards [2]hand.Card
+	Type  hand.HandType
+}
+
+// CardOuts is what one unseen card does for each player.
+type CardOuts struct {
+	Card    hand.Card
+	Players []CardEffect
+}
+
+// CardEffect is one player's hand once a card is dealt.
+type CardEffect struct {
+	Type     hand.HandType // hand type with the card
+	Improves bool          // the type beats the one before the card
+	Ahead    bool          // the best hand alone with the card
+	Out      bool          // puts the player ahead from behind or tied
+	Tainted  bool          // an out that also improves an opponent's hand type
+}
+
+// Outs lists what every unseen card does for the players of game with the given hole
+// cards on a flop or turn community (3 or 4 cards): the hand type each player makes,
+// whether it puts them ahead, and which of those outs are tainted because they also
+// improve an opponent. On a flop it also lists each player's runner-runner pairs.
+// Unseen cards are the deck (with w's jokers) less the hole and community cards.
+// Returns ErrInvalidInput for bad input or a game without a board or with a low half.
+func Outs(game hand.Variant, w hand.Wilds, holes [][]hand.Card, community []hand.Card) (OutsResult, error) {
+	n := len(holes)
+	if n < 2 || (len(community) != 3 && len(community) != 4) || game.Stud() || game.HiLo() {
+		return OutsResult{}, ErrInvalidInput
+	}
+	dead := hand.NewCardSet(community...)
+	nKnown := len(community)
+	for _, h := range holes {
+		if !game.ValidHoleCount(len(h)) {
+			return OutsResult{}, ErrInvalidInput
+		}
+		dead = dead.Union(hand.NewCardSet(h...))
+		nKnown += len(h)
+	}
+	if dead.Count() != nKnown {
+		return OutsResult{}, ErrInvalidInput // duplicate cards
+	}
+	live := game.Rules().Cards()
+	for i := 1; i <= w.Jokers; i++ {
+		live = live.Add(hand.Joker(i))
+	}
+	unseen := (live &^ dead).Cards()
+
+	rules := game.Rules()
+	evaluate := func(board []hand.Card, vals []hand.Strength) {
+		for i, h := range holes {
+			vals[i] = game.EvaluateWild(h, board, w)
+		}
+	}
+	// alone reports whether player i holds the best hand alone.
+	alone := func(vals []hand.Strength, i int) bool {
+		best, nBest := bestOf(vals)
+		return vals[i] == best && nBest == 1
+	}
+
+	res := OutsResult{Players: make([]PlayerOuts, n), Cards: make([]CardOuts, len(unseen))}
+	now := make([]hand.Strength, n)
+	evaluate(community, now)
+	for i := range res.Players {
+		res.Players[i].Type = rules.Type(now[i])
+		res.Players[i].Ahead = alone(now, i)
+	}
+	board := append(make([]hand.Card, 0, 5), community...)
+	outs := make([]hand.CardSet, n) // per player, to find runner-runner pairs
+	for k, c := range unseen {
+		vals := make([]hand.Strength, n)
+		evaluate(append(board, c), vals)
+		effects := make([]CardEffect, n)
+		for i := range effects {
+			t := rules.Type(vals[i])
+			// Strengths order the hand types as the rules rank them.
+			improves := t != res.Players[i].Type && vals[i] > now[i]
+			effects[i] = CardEffect{Type: t, Improves: improves, Ahead: alone(vals, i)}
+		}
+		for i := range effects {
+			e := &effects[i]
+			if !e.Ahead || res.Players[i].Ahead {
+				continue
+			}
+			e.Out = true
+			for j := range effects {
+				if j != i && effects[j].Improves {
+					e.Tainted = true
+				}
+			}
+			p := &res.Players[i]
+			p.Outs = append(p.Outs, c)
+			if e.Tainted {
+				p.Tainted = append(p.Tainted, c)
+			}
+			outs[i] = outs[i].Add(c)
+		}
+		res.Cards[k] = CardOuts{Card: c, Players: effects}
+	}
+
+	if len(community) == 3 {
+		vals := make([]hand.Strength, n)
+		for a := range unseen {
+			for b := a + 1; b < len(unseen); b++ {
+				pair := hand.NewCardSet(unseen[a], unseen[b])
+				evaluate(append(board, unseen[a], unseen[b]), vals)
+				for i := range res.Players {
+					p := &res.Players[i]
+					if p.Ahead || outs[i]&pair != 0 || !alone(vals, i) {
+						continue
+					}
+					p.RunnerRunner = append(p.RunnerRunner, RunnerOut{Cards: [2]hand.Card{unseen[a], unseen[b]}, Type: rules.Type(vals[i])})
+				}
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,82 @@
+package montecarlo
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"texashold-backend/hand"
+)
+
+func TestOuts(t *testing.T) {
+	// A nut flush draw against a set, with a gutshot in the third seat, on the turn.
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9"), cards(t, "CT C8")}
+	res, err := Outs(hand.Holdem, hand.Wilds{}, holes, cards(t, "H9 H5 C6 D2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Cards) != 52-6-4 {
+		t.Fatalf("%d unseen cards", len(res.Cards))
+	}
+	flush, set := res.Players[0], res.Players[1]
+	if flush.Type != hand.HighCard || flush.Ahead || set.Type != hand.ThreeOfAKind || !set.Ahead || set.Outs != nil {
+		t.Errorf("now: %+v, %+v", flush, set)
+	}
+	// Hearts pairing the board fill the set up.
+	if got, want := flush.Outs, cards(t, "H3 H4 H7 H8 HT HJ HQ"); !slices.Equal(got, want) {
+		t.Errorf("flush outs %v, want %v", got, want)
+	}
+	// The seven makes the third seat a straight; the eight and ten pair it.
+	if got, want := flush.Tainted, cards(t, "H7 H8 HT"); !slices.Equal(got, want) {
+		t.Errorf("tainted %v, want %v", got, want)
+	}
+	if got, want := res.Players[2].Outs, cards(t, "S7 D7 C7"); !slices.Equal(got, want) {
+		t.Errorf("gutshot outs %v, want %v", got, want)
+	}
+	for _, c := range res.Cards {
+		e := c.Players[0]
+		if e.Out != slices.Contains(flush.Outs, c.Card) || e.Tainted != slices.Contains(flush.Tainted, c.Card) {
+			t.Errorf("%s: %+v", c.Card, e)
+		}
+		if c.Card == cards(t, "H6")[0] && (c.Players[1].Type != hand.FullHouse || !c.Players[1].Improves || !c.Players[1].Ahead) {
+			t.Errorf("six of hearts for the set: %+v", c.Players[1])
+		}
+	}
+	if flush.RunnerRunner != nil {
+		t.Errorf("runner-runner on the turn: %v", flush.RunnerRunner)
+	}
+
+	// Backdoor draws against a flopped set: no single card helps.
+	holes = [][]hand.Card{cards(t, "HJ HT"), cards(t, "SA DA")}
+	res, err = Outs(hand.Holdem, hand.Wilds{}, holes, cards(t, "HA C7 D2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	draw := res.Players[0]
+	if draw.Outs != nil || res.Players[1].RunnerRunner != nil {
+		t.Errorf("outs %v, set runner-runner %v", draw.Outs, res.Players[1].RunnerRunner)
+	}
+	found := map[[2]hand.Card]hand.HandType{}
+	for _, r := range draw.RunnerRunner {
+		found[r.Cards] = r.Type
+	}
+	for pair, want := range map[string]hand.HandType{"H3 H4": hand.Flush, "SK CQ": hand.Straight, "H9 H8": hand.Flush} {
+		c := cards(t, pair)
+		if a, b := c[0], c[1]; a.Index() > b.Index() {
+			c[0], c[1] = b, a
+		}
+		if got, ok := found[[2]hand.Card(c)]; !ok || got != want {
+			t.Errorf("runner-runner %s: %v %v, want %v", pair, got, ok, want)
+		}
+	}
+	// Runner hearts that pair the board give the set a full house.
+	if t2, ok := found[[2]hand.Card(cards(t, "H2 H3"))]; ok {
+		t.Errorf("H2 H3 listed as %v", t2)
+	}
+
+	for _, bad := range [][]hand.Card{cards(t, "HA C7"), cards(t, "HA C7 D2 S3 S4"), cards(t, "HA C7 HJ")} {
+		if _, err := Outs(hand.Holdem, hand.Wilds{}, holes, bad); !errors.Is(err, ErrInvalidInput) {
+			t.Errorf("%v: err %v", bad, err)
+		}
+	}
+}
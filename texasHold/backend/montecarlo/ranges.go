@@ -0,0 +1,316 @@
+package montecarlo
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sort"
+	"sync/atomic"
+
+	"texashold-backend/hand"
+	"texashold-backend/ranges"
+)
+
+// ErrImpossibleRanges is returned when the players' ranges cannot all be dealt at once:
+// every combination of their combos shares a card or uses a known card, or the deals
+// that fit carry so little of the ranges' weight that one takes more than
+// maxRangeRedraws draws to find.
+var ErrImpossibleRanges = errors.New("montecarlo: no deal fits every player's range")
+
+// maxRangeRedraws caps the draws of the ranges in one simulation before RangeEquity
+// gives up on them. Deals that fit one time in this many are left to ImportanceSampling.
+const maxRangeRedraws = 1 << 16
+
+// Player is one player of an equity calculation: known hole cards, a weighted range
+// (two-card games only), or neither for a uniformly random hand.
+type Player struct {
+	Hole  []hand.Card
+	Range *ranges.Range
+}
+
+// rangeSampler draws combos from a range with probability proportional to their weights.
+type rangeSampler struct {
+	combos []ranges.Combo
+	cum    []float64 // cumulative weights
+}
+
+func newRangeSampler(r *ranges.Range) rangeSampler {
+	wc := r.Combos()
+	s := rangeSampler{combos: make([]ranges.Combo, len(wc)), cum: make([]float64, len(wc))}
+	total := 0.0
+	for i, c := range wc {
+		total += c.Weight
+		s.combos[i], s.cum[i] = c.Combo, total
+	}
+	return s
+}
+
+func (s rangeSampler) draw(r *rand.Rand) ranges.Combo {
+	u := r.Float64() * s.cum[len(s.cum)-1]
+	i := sort.Search(len(s.cum), func(i int) bool { return s.cum[i] > u })
+	return s.combos[min(i, len(s.combos)-1)]
+}
+
+func (s rangeSampler) total() float64 {
+	return s.cum[len(s.cum)-1]
+}
+
+// drawWithout draws a combo by weight from those that miss the cards in dead, with cum,
+// as long as the range, to hold their cumulative weights. It returns the combo and the
+// total weight of those combos, 0 when none is left.
+func (s rangeSampler) drawWithout(r *rand.Rand, dead hand.CardSet, cum []float64) (ranges.Combo, float64) {
+	if dead == 0 {
+		return s.draw(r), s.total()
+	}
+	w, prev := 0.0, 0.0
+	for i, c := range s.combos {
+		if c.CardSet()&dead == 0 {
+			w += s.cum[i] - prev
+		}
+		prev, cum[i] = s.cum[i], w
+	}
+	if w == 0 {
+		return ranges.Combo{}, 0
+	}
+	// Blocked combos add nothing, so the first cumulative weight above u is a live one.
+	u := r.Float64() * w
+	i := sort.Search(len(cum), func(i int) bool { return cum[i] > u })
+	return s.combos[min(i, len(s.combos)-1)], w
+}
+
+// drawInTurn deals the ranged players one after another into holes, each by weight
+// from the combos that miss the cards dealt before, with cums as drawWithout's scratch
+// for each player. It returns the cards dealt and the importance weight of the deal,
+// the product of the shares of each range's weight left to it, or 0 when one has
+// nothing left: the deal is then weighted as often as rejecting colliding draws would
+// keep it.
+func drawInTurn(samplers []rangeSampler, ranged []int, r *rand.Rand, holes [][]hand.Card, cums [][]float64) (drawn hand.CardSet, weight float64) {
+	weight = 1
+	for _, i := range ranged {
+		sp := samplers[i]
+		c, left := sp.drawWithout(r, drawn, cums[i])
+		if left == 0 {
+			return drawn, 0
+		}
+		weight *= left / sp.total()
+		drawn |= c.CardSet()
+		holes[i][0], holes[i][1] = c.A, c.B
+	}
+	return drawn, weight
+}
+
+// RangeEquity runs up to nSims simulations of game between players, each with known
+// hole cards, a range or a random hand, and reports every player's win, tie and equity.
+// Ranges lose the combos blocked by the known cards and the board. In each sim every
+// range is drawn by weight and the whole draw is rejected if two combos share a card, so
+// overlapping ranges are dealt in proportion to the product of their weights, as they
+// would be dealt at a real table; random hands and the board come from the rest of the
+// deck. Ranges need a game with two hole cards; random hands get two as well, or as many
+// as the first player with known cards. It returns ErrInvalidInput for bad input and
+// ErrImpossibleRanges when no deal fits every range, or when a simulation runs out of
+// redraws.
+func (s Simulator) RangeEquity(ctx context.Context, game hand.Variant, w hand.Wilds, players []Player, community []hand.Card, nSims int) (Result, error) {
+	nPlayers := len(players)
+	if nPlayers < 2 || nSims <= 0 || game.Stud() || game.HiLo() {
+		return Result{}, ErrInvalidInput
+	}
+	nHole, hasRange := 0, false
+	dead := hand.NewCardSet(community...)
+	nKnown := len(community)
+	for _, p := range players {
+		switch {
+		case p.Range != nil && len(p.Hole) > 0:
+			return Result{}, ErrInvalidInput
+		case p.Range != nil:
+			hasRange = true
+		case len(p.Hole) > 0:
+			if !game.ValidHoleCount(len(p.Hole)) {
+				return Result{}, ErrInvalidInput
+			}
+			if nHole == 0 {
+				nHole = len(p.Hole)
+			}
+			dead = dead.Union(hand.NewCardSet(p.Hole...))
+			nKnown += len(p.Hole)
+		}
+	}
+	if dead.Count() != nKnown {
+		return Result{}, ErrInvalidInput // duplicate cards
+	}
+	if nHole == 0 {
+		nHole = 2
+	}
+	if hasRange && (nHole != 2 || !game.ValidHoleCount(2)) {
+		return Result{}, ErrInvalidInput
+	}
+	nDealt := 5 - len(community)
+	for _, p := range players {
+		if len(p.Hole) == 0 {
+			nDealt += nHole
+		}
+	}
+	if game.Rules().NewWildDeck(dead, w).Remaining() < nDealt {
+		return Result{}, ErrInvalidInput
+	}
+
+	// Ranges never hold jokers, and lose the cards outside the game's deck.
+	blocked := dead | (hand.FullCardSet &^ game.Rules().Cards())
+	samplers := make([]rangeSampler, nPlayers)
+	var ranged []int
+	for i, p := range players {
+		if p.Range == nil {
+			continue
+		}
+		r := p.Range.Without(blocked)
+		if r.Len() == 0 {
+			return Result{}, ErrImpossibleRanges
+		}
+		samplers[i] = newRangeSampler(r)
+		ranged = append(ranged, i)
+	}
+	if !rangesFit(samplers, ranged) {
+		return Result{}, ErrImpossibleRanges
+	}
+
+	result := s.playersResult(nPlayers, len(community))
+	width := s.showdownWidth(nPlayers)
+	importance := s.Sampling == ImportanceSampling && len(ranged) > 0
+	if importance {
+		result = func(sums []float64, sims int) Result {
+			return batchResult(sums, sims, nPlayers, ImportanceSampling)
+		}
+		width = batchWidth(nPlayers)
+	}
+	// A simulation that runs out of redraws stops the run; one still redrawing when ctx
+	// is done gives up and is not counted.
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var stuck atomic.Bool
+	var lost atomic.Int64 // simulations cut short when ctx was done
+	sums, sims, stop := s.run(runCtx, nSims, width, func() trial {
+		deck := game.Rules().NewWildDeck(dead, w)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		holes := make([][]hand.Card, nPlayers)
+		for i, p := range players {
+			if len(p.Hole) > 0 {
+				holes[i] = p.Hole
+			} else {
+				holes[i] = make([]hand.Card, nHole)
+			}
+		}
+		vals := make([]hand.Strength, nPlayers)
+		var turn []hand.Strength
+		if s.HandTypes && len(community) == 3 && !importance {
+			turn = make([]hand.Strength, nPlayers)
+		}
+		var b *batch
+		var cums [][]float64
+		if importance {
+			b = newBatch(1, nPlayers)
+			cums = make([][]float64, nPlayers)
+			for _, i := range ranged {
+				cums[i] = make([]float64, len(samplers[i].combos))
+			}
+		}
+		return func(r *rand.Rand, tally []float64) {
+			if stuck.Load() {
+				return
+			}
+			var drawn hand.CardSet
+			weight := 1.0
+			if importance {
+				if drawn, weight = drawInTurn(samplers, ranged, r, holes, cums); weight == 0 {
+					b.add(vals, 0, tally)
+					return
+				}
+			}
+			// Otherwise draw every range until no two combos collide.
+			redraws := 0
+		draw:
+			for !importance {
+				if redraws++; redraws > maxRangeRedraws {
+					stuck.Store(true)
+					cancel()
+					return
+				} else if redraws%1024 == 0 && ctx.Err() != nil {
+					lost.Add(1)
+					return
+				}
+				drawn = 0
+				for _, i := range ranged {
+					c := samplers[i].draw(r)
+					cs := c.CardSet()
+					if drawn&cs != 0 {
+						continue draw
+					}
+					drawn |= cs
+					holes[i][0], holes[i][1] = c.A, c.B
+				}
+				break
+			}
+			deck.Shuffle(r)
+			deal := func() hand.Card {
+				for {
+					if c := deck.Deal(); !drawn.Contains(c) {
+						return c
+					}
+				}
+			}
+			for i := len(community); i < 5; i++ {
+				board[i] = deal()
+			}
+			for i, p := range players {
+				if len(p.Hole) == 0 && p.Range == nil {
+					for j := range holes[i] {
+						holes[i][j] = deal()
+					}
+				}
+			}
+			for i := range players {
+				vals[i] = game.EvaluateWild(holes[i], board, w)
+				if turn != nil {
+					turn[i] = game.EvaluateWild(holes[i], board[:4], w)
+				}
+			}
+			if importance {
+				b.add(vals, weight, tally)
+				return
+			}
+			s.tallyPlayers(game.Rules(), turn, vals, tally)
+		}
+	}, s.doneAt(result))
+	if stuck.Load() {
+		return Result{}, ErrImpossibleRanges
+	}
+	res := result(sums, sims-int(lost.Load()))
+	res.Stop = stop
+	return res, nil
+}
+
+// rangesFit reports whether the ranged players' samplers can all be dealt at once,
+// searching the smallest ranges first and remembering the card sets that fail.
+func rangesFit(samplers []rangeSampler, ranged []int) bool {
+	order := append([]int(nil), ranged...)
+	sort.SliceStable(order, func(a, b int) bool {
+		return len(samplers[order[a]].combos) < len(samplers[order[b]].combos)
+	})
+	failed := make(map[hand.CardSet]bool)
+	var fit func(k int, used hand.CardSet) bool
+	fit = func(k int, used hand.CardSet) bool {
+		if k == len(order) {
+			return true
+		}
+		if failed[used] {
+			return false
+		}
+		for _, c := range samplers[order[k]].combos {
+			if cs := c.CardSet(); used&cs == 0 && fit(k+1, used|cs) {
+				return true
+			}
+		}
+		failed[used] = true
+		return false
+	}
+	return fit(0, 0)
+}
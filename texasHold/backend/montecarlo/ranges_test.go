@@ -0,0 +1,113 @@
+package montecarlo
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"texashold-backend/hand"
+	"texashold-backend/ranges"
+)
+
+func TestRangeEquity(t *testing.T) {
+	ctx := context.Background()
+	board := cards(t, "HQ HJ D2 C3 S7")
+	hero := cards(t, "HA HK")
+	rangeA, rangeB := ranges.MustParse("AA, QQ, JJ:0.5, AKs"), ranges.MustParse("AA:0.25, QJ, 72o")
+
+	// On the river the ranges are the only randomness: weigh every pair of combos that
+	// fit together by the product of their weights.
+	want := make([]float64, 3)
+	var total float64
+	blocked := hand.NewCardSet(board...).Union(hand.NewCardSet(hero...))
+	for _, a := range rangeA.Without(blocked).Combos() {
+		for _, b := range rangeB.Without(blocked | a.CardSet()).Combos() {
+			wt := a.Weight * b.Weight
+			vals := []hand.Strength{
+				hand.Holdem.Evaluate(hero, board),
+				hand.Holdem.Evaluate(a.Cards(), board),
+				hand.Holdem.Evaluate(b.Cards(), board),
+			}
+			tally := make([]float64, 3*showdownSlots)
+			tallyShowdown(vals, tally)
+			for i := range want {
+				want[i] += wt * tally[i*showdownSlots+2]
+			}
+			total += wt
+		}
+	}
+	players := []Player{{Hole: hero}, {Range: rangeA}, {Range: rangeB}}
+	res, err := Simulator{Seed: 5}.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, board, 50000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := 0.0
+	for i, p := range res.Players {
+		if math.Abs(p.Equity.Value-want[i]/total) > 3*p.Equity.StdErr+1e-9 {
+			t.Errorf("player %d equity %+v, want %v", i, p.Equity, want[i]/total)
+		}
+		sum += p.Equity.Value
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Errorf("equities sum to %v", sum)
+	}
+
+	// A one-combo range is a known hand.
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9")}
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, cards(t, "H9 H5 C2"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	players = []Player{{Hole: holes[0]}, {Range: ranges.MustParse("9s9d")}}
+	res, err = Simulator{Seed: 5}.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, cards(t, "H9 H5 C2"), 20000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := res.Players[0]; math.Abs(p.Equity.Value-exact.Players[0].Equity.Value) > 3*p.Equity.StdErr {
+		t.Errorf("one-combo range: equity %+v, exact %v", p.Equity, exact.Players[0].Equity.Value)
+	}
+
+	// Random hands alongside ranges, preflop.
+	players = []Player{{Range: ranges.MustParse("22+, ATs+, KQo")}, {}, {Range: ranges.MustParse("AA")}}
+	res, err = Simulator{Seed: 5}.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, 5000)
+	if err != nil || res.Players[2].Equity.Value < 0.5 {
+		t.Errorf("preflop: %+v, %v", res.Players, err)
+	}
+}
+
+func TestImpossibleRanges(t *testing.T) {
+	ctx := context.Background()
+	for _, players := range [][]Player{
+		// Only two players can hold aces.
+		{{Range: ranges.MustParse("AA")}, {Range: ranges.MustParse("AA")}, {Range: ranges.MustParse("AA")}},
+		// The board blocks every combo.
+		{{Hole: cards(t, "HA HK")}, {Range: ranges.MustParse("QhJh")}},
+	} {
+		_, err := Simulator{Seed: 1}.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, cards(t, "HQ HJ D2"), 100)
+		if !errors.Is(err, ErrImpossibleRanges) {
+			t.Errorf("%v: err %v", players, err)
+		}
+	}
+	// Fitting deals carry a billionth of the weight: plain sampling gives up on the
+	// redraws rather than spinning.
+	players := []Player{{Range: ranges.MustParse("AsAh")}, {Range: ranges.MustParse("AsAh, KK:0.000000001")}}
+	if _, err := (Simulator{Seed: 1}).RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, 100000); !errors.Is(err, ErrImpossibleRanges) {
+		t.Errorf("unlikely fit: err %v", err)
+	}
+	// One in a few thousand still fits, slowly. Once ctx is done a simulation still
+	// redrawing gives up and is not counted, so even the first chunk falls short.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	players[1].Range = ranges.MustParse("AsAh, KK:0.0001")
+	res, err := Simulator{Seed: 1}.RangeEquity(cancelled, hand.Holdem, hand.Wilds{}, players, nil, 10000000)
+	if err != nil || res.Stop != StopCancelled || res.Sims >= chunkSims {
+		t.Errorf("slow fit: %v after %d sims, %v", res.Stop, res.Sims, err)
+	}
+
+	// Ranges in Omaha.
+	players = []Player{{Hole: cards(t, "HA HK SA SK")}, {Range: ranges.MustParse("AA")}}
+	if _, err := (Simulator{Seed: 1}).RangeEquity(ctx, hand.Omaha, hand.Wilds{}, players, nil, 100); !errors.Is(err, ErrInvalidInput) {
+		t.Errorf("omaha: err %v", err)
+	}
+}
@@ -0,0 +1,88 @@
+package montecarlo
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+)
+
+// RNG selects the random number generator of a Simulator.
+type RNG int
+
+const (
+	PCG        RNG = iota // math/rand/v2 PCG, fast; the default
+	ChaCha8               // math/rand/v2 ChaCha8, a cryptographically strong seeded stream
+	CryptoRand            // crypto/rand, for audits; ignores the seed, so results do not repeat
+)
+
+func (g RNG) String() string {
+	switch g {
+	case PCG:
+		return "pcg"
+	case ChaCha8:
+		return "chacha8"
+	case CryptoRand:
+		return "crypto"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRNG parses an RNG name: "pcg" (also ""), "chacha8" or "crypto".
+func ParseRNG(s string) (RNG, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "pcg":
+		return PCG, nil
+	case "chacha8":
+		return ChaCha8, nil
+	case "crypto", "crypto/rand":
+		return CryptoRand, nil
+	default:
+		return PCG, fmt.Errorf("unknown rng: %q (want pcg, chacha8 or crypto)", s)
+	}
+}
+
+// Deterministic reports whether g gives the same stream for the same seed.
+func (g RNG) Deterministic() bool {
+	return g != CryptoRand
+}
+
+// stream returns the random source of chunk number chunk under seed.
+func (g RNG) stream(seed, chunk uint64) rand.Source {
+	switch g {
+	case ChaCha8:
+		var key [32]byte
+		binary.LittleEndian.PutUint64(key[0:], seed)
+		binary.LittleEndian.PutUint64(key[8:], chunk)
+		return rand.NewChaCha8(key)
+	case CryptoRand:
+		return &cryptoSource{}
+	default:
+		return rand.NewPCG(seed, chunk)
+	}
+}
+
+// cryptoSource reads crypto/rand in blocks.
+type cryptoSource struct {
+	buf [512]byte
+	pos int
+}
+
+func (s *cryptoSource) Uint64() uint64 {
+	if s.pos == 0 {
+		if _, err := crand.Read(s.buf[:]); err != nil {
+			panic("montecarlo: crypto/rand: " + err.Error())
+		}
+	}
+	v := binary.LittleEndian.Uint64(s.buf[s.pos:])
+	s.pos = (s.pos + 8) % len(s.buf)
+	return v
+}
+
+// NewSeed returns a random seed for a Simulator. It fits in 53 bits, so it survives a
+// round trip through JSON numbers in JavaScript.
+func NewSeed() uint64 {
+	return rand.Uint64() >> 11
+}
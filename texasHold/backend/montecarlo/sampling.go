@@ -0,0 +1,375 @@
+package montecarlo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"strings"
+
+	"texashold-backend/hand"
+)
+
+// Sampling selects how a Simulator draws its runouts. Every strategy estimates the
+// same equities as plain sampling; they differ in the variance of the estimates.
+type Sampling int
+
+const (
+	// PlainSampling draws every runout independently; the default.
+	PlainSampling Sampling = iota
+	// StratifiedSampling splits the deals of the next street (the flop, or the turn or
+	// river card) into stratumBatch strata of equal probability, ordered by their
+	// suit-isomorphism class, and draws one deal from each stratum per batch. It applies
+	// to WinProbability and WinProbabilityMulti with cards to come.
+	StratifiedSampling
+	// AntitheticSampling plays each runout along with its mirror image, the suits
+	// rotated and the ranks reversed, so a high board is balanced by a low one and a
+	// flush in one suit by one in the next; random opponents' hands are mirrored too. It
+	// applies to WinProbability and WinProbabilityMulti with cards to come.
+	AntitheticSampling
+	// ImportanceSampling deals the ranges one after another from the combos the earlier
+	// ones leave, with no redraws, and weights each deal by how likely the ranges were to
+	// fit together. It applies to RangeEquity with a ranged player.
+	ImportanceSampling
+)
+
+func (g Sampling) String() string {
+	switch g {
+	case PlainSampling:
+		return "plain"
+	case StratifiedSampling:
+		return "stratified"
+	case AntitheticSampling:
+		return "antithetic"
+	case ImportanceSampling:
+		return "importance"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseSampling parses a sampling name: "plain" (also ""), "stratified", "antithetic"
+// or "importance".
+func ParseSampling(s string) (Sampling, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "plain":
+		return PlainSampling, nil
+	case "stratified":
+		return StratifiedSampling, nil
+	case "antithetic":
+		return AntitheticSampling, nil
+	case "importance":
+		return ImportanceSampling, nil
+	default:
+		return PlainSampling, fmt.Errorf("unknown sampling: %q (want plain, stratified, antithetic or importance)", s)
+	}
+}
+
+// Runouts per batch of each strategy. They divide chunkSims, so every chunk holds whole
+// batches once nSims is rounded up to a multiple.
+const (
+	stratumBatch    = 64
+	antitheticBatch = 2
+)
+
+// batchSlots is the width of one player's outcome in a batch tally. Each batch is one
+// sample: for wins, ties and equity the sums of its weighted total Y, of Y² and of Y
+// times the batch weight W, then the weighted sum of the squared equity of each runout.
+// batchTotals more slots end the tally: the sums of W and of W², and the batches.
+const (
+	batchSlots  = 10
+	batchTotals = 3
+)
+
+// batchWidth returns the tally width of a batched showdown between n players.
+func batchWidth(n int) int {
+	return n*batchSlots + batchTotals
+}
+
+// batch accumulates the runouts of one batch of a variance-reduced run, each weighted,
+// and adds it to the tally as one sample once it holds size runouts.
+type batch struct {
+	size, runs int
+	w          float64   // total weight so far
+	t          []float64 // per player: weighted wins, ties, equity and equity squared
+}
+
+func newBatch(size, n int) *batch {
+	return &batch{size: size, t: make([]float64, n*showdownSlots)}
+}
+
+// add adds a runout with the players' scores vals and weight w; a zero weight is a
+// runout that counts but was not played, and vals is then ignored. Players past those
+// the batch reports only take part in the showdown.
+func (b *batch) add(vals []hand.Strength, w float64, tally []float64) {
+	if w != 0 {
+		best, nBest := bestOf(vals)
+		share := 1 / float64(nBest)
+		for i, v := range vals[:len(b.t)/showdownSlots] {
+			if v != best {
+				continue
+			}
+			t := b.t[i*showdownSlots:]
+			if nBest == 1 {
+				t[0] += w
+			} else {
+				t[1] += w
+			}
+			t[2] += w * share
+			t[3] += w * share * share
+		}
+		b.w += w
+	}
+	if b.runs++; b.runs < b.size {
+		return
+	}
+	n := len(b.t) / showdownSlots
+	for i := 0; i < n; i++ {
+		t, out := b.t[i*showdownSlots:], tally[i*batchSlots:]
+		for k := 0; k < 3; k++ {
+			out[3*k] += t[k]
+			out[3*k+1] += t[k] * t[k]
+			out[3*k+2] += t[k] * b.w
+		}
+		out[9] += t[3]
+	}
+	totals := tally[n*batchSlots:]
+	totals[0] += b.w
+	totals[1] += b.w * b.w
+	totals[2]++
+	clear(b.t)
+	b.w, b.runs = 0, 0
+}
+
+// batchResult turns a batch tally of n players over sims runouts into a Result. Each
+// value is the ratio of the weighted outcomes to the weights, and its standard error
+// comes from the spread of the batches, so it is the variance the strategy achieved.
+func batchResult(sums []float64, sims, n int, sampling Sampling) Result {
+	totals := sums[n*batchSlots:]
+	w, w2, batches := totals[0], totals[1], totals[2]
+	res := Result{Players: make([]PlayerEquity, n), Sims: sims, Sampling: sampling}
+	if w == 0 {
+		return res
+	}
+	est := func(y, y2, yw float64) Estimate {
+		mean := y / w
+		var se float64
+		if batches > 1 {
+			v := (y2 - 2*mean*yw + mean*mean*w2) / (w * w) * batches / (batches - 1)
+			se = math.Sqrt(math.Max(v, 0))
+		}
+		return NewEstimate(mean, se)
+	}
+	res.ESS = math.Inf(1)
+	for i := range res.Players {
+		t := sums[i*batchSlots:]
+		p := PlayerEquity{Win: est(t[0], t[1], t[2]), Tie: est(t[3], t[4], t[5]), Equity: est(t[6], t[7], t[8])}
+		res.Players[i] = p
+		// The variance of one plain runout, over the variance achieved.
+		if v := p.Equity.StdErr * p.Equity.StdErr; v > 0 {
+			res.ESS = min(res.ESS, (t[9]/w-p.Equity.Value*p.Equity.Value)/v)
+		}
+	}
+	if math.IsInf(res.ESS, 1) {
+		res.ESS = float64(sims)
+	}
+	res.Variance = res.equityVariance()
+	return res
+}
+
+// roundUp returns n rounded up to a multiple of size.
+func roundUp(n, size int) int {
+	return (n + size - 1) / size * size
+}
+
+// liveCards returns the cards of game's deck with w's jokers, except those in dead, in
+// index order.
+func liveCards(game hand.Variant, dead hand.CardSet, w hand.Wilds) []hand.Card {
+	deck := game.Rules().NewWildDeck(dead, w)
+	cards := make([]hand.Card, 0, deck.Remaining())
+	for deck.Remaining() > 0 {
+		cards = append(cards, deck.Deal())
+	}
+	return cards
+}
+
+// streetDeals returns every deal of k of the live cards, k at a time in one slice,
+// ordered by the suit-isomorphism class of the deal: ranks first, high to low, then
+// suit pattern. Equal-probability runs of the list are the strata of StratifiedSampling.
+func streetDeals(live []hand.Card, k int) []hand.Card {
+	type deal struct {
+		cards [3]hand.Card
+		key   int
+	}
+	var deals []deal
+	var pick [3]hand.Card
+	var rec func(from, depth int)
+	rec = func(from, depth int) {
+		if depth == k {
+			_, canon, _ := hand.Canonicalize(nil, pick[:k])
+			key, suits := 0, 0
+			for _, c := range canon {
+				key = key*16 + c.Rank
+				suits = suits*4 + c.Index()/13
+			}
+			deals = append(deals, deal{cards: pick, key: key<<6 | suits})
+			return
+		}
+		for i := from; i < len(live); i++ {
+			pick[depth] = live[i]
+			rec(i+1, depth+1)
+		}
+	}
+	rec(0, 0)
+	sort.SliceStable(deals, func(a, b int) bool { return deals[a].key > deals[b].key })
+	out := make([]hand.Card, 0, k*len(deals))
+	for _, d := range deals {
+		out = append(out, d.cards[:k]...)
+	}
+	return out
+}
+
+// suitRotation returns the rotation of the live cards: each goes to the next live card
+// of its rank in suit order, the last back to the first, so with nothing dead hearts
+// turn into spades, spades into diamonds, diamonds into clubs and clubs into hearts.
+// Jokers stay put. It is indexed by Card.Index.
+func suitRotation(live []hand.Card) [54]hand.Card {
+	var rot [54]hand.Card
+	var byRank [13][]hand.Card
+	for _, c := range live {
+		rot[c.Index()] = c
+		if !c.IsJoker() {
+			byRank[c.Rank] = append(byRank[c.Rank], c)
+		}
+	}
+	for _, cards := range byRank {
+		for i, c := range cards {
+			rot[c.Index()] = cards[(i+1)%len(cards)]
+		}
+	}
+	return rot
+}
+
+// rankReflection returns the reflection of the live cards within each suit: the
+// lowest live card of a suit goes to the highest and back, the second lowest to the
+// second highest, and so on. Jokers stay put. It is indexed by Card.Index.
+func rankReflection(live []hand.Card) [54]hand.Card {
+	var ref [54]hand.Card
+	var bySuit [4][]hand.Card
+	for _, c := range live {
+		ref[c.Index()] = c
+		if !c.IsJoker() {
+			bySuit[c.Index()/13] = append(bySuit[c.Index()/13], c)
+		}
+	}
+	for _, cards := range bySuit {
+		for i, c := range cards {
+			ref[c.Index()] = cards[len(cards)-1-i]
+		}
+	}
+	return ref
+}
+
+// mirror returns the partner AntitheticSampling plays with each live card: its suit
+// rotation, reflected. Both are bijections of the live cards, so the mirror image of a
+// uniform runout is uniform too. It is indexed by Card.Index.
+func mirror(live []hand.Card) [54]hand.Card {
+	rot, ref := suitRotation(live), rankReflection(live)
+	var m [54]hand.Card
+	for _, c := range live {
+		m[c.Index()] = ref[rot[c.Index()].Index()]
+	}
+	return m
+}
+
+// sampled is WinProbabilityMulti, or WinProbability with nRandom random opponents, under
+// s.Sampling, StratifiedSampling or AntitheticSampling, for a board with cards to come;
+// its inputs are checked. It reports the players with known holes; the random
+// opponents get as many hole cards as the first of them.
+func (s Simulator) sampled(ctx context.Context, game hand.Variant, w hand.Wilds, holes [][]hand.Card, nRandom int, community []hand.Card, dead hand.CardSet, nSims int) Result {
+	nKnown := len(holes)
+	live := liveCards(game, dead, w)
+	var size, k int
+	var deals []hand.Card
+	var partner [54]hand.Card
+	if s.Sampling == StratifiedSampling {
+		size, k = stratumBatch, 1
+		if len(community) == 0 {
+			k = 3
+		}
+		deals = streetDeals(live, k)
+	} else {
+		size = antitheticBatch
+		partner = mirror(live)
+	}
+	nDeals := len(deals) / max(k, 1)
+	result := func(sums []float64, sims int) Result {
+		return batchResult(sums, sims, nKnown, s.Sampling)
+	}
+	sums, sims, stop := s.run(ctx, roundUp(nSims, size), batchWidth(nKnown), func() trial {
+		deck := game.Rules().NewWildDeck(dead, w)
+		b := newBatch(size, nKnown)
+		board := make([]hand.Card, 5)
+		copy(board, community)
+		players := append(make([][]hand.Card, 0, nKnown+nRandom), holes...)
+		for o := 0; o < nRandom; o++ {
+			players = append(players, make([]hand.Card, len(holes[0])))
+		}
+		opponents := players[nKnown:]
+		vals := make([]hand.Strength, len(players))
+		return func(r *rand.Rand, tally []float64) {
+			j := b.runs
+			if s.Sampling == StratifiedSampling {
+				// Stratum j of the batch: the deals from j/size to (j+1)/size of the way down.
+				d := min(int((float64(j)+r.Float64())*float64(nDeals)/float64(size)), nDeals-1)
+				street := deals[d*k : (d+1)*k]
+				copy(board[len(community):], street)
+				drawn := hand.NewCardSet(street...)
+				deck.Shuffle(r)
+				deal := func() hand.Card {
+					c := deck.Deal()
+					for drawn.Contains(c) {
+						c = deck.Deal()
+					}
+					return c
+				}
+				for i := len(community) + k; i < 5; i++ {
+					board[i] = deal()
+				}
+				for _, h := range opponents {
+					for i := range h {
+						h[i] = deal()
+					}
+				}
+			} else if j == 0 {
+				// The batch's runout, then its mirror image.
+				deck.Shuffle(r)
+				for i := len(community); i < 5; i++ {
+					board[i] = deck.Deal()
+				}
+				for _, h := range opponents {
+					for i := range h {
+						h[i] = deck.Deal()
+					}
+				}
+			} else {
+				for i := len(community); i < 5; i++ {
+					board[i] = partner[board[i].Index()]
+				}
+				for _, h := range opponents {
+					for i := range h {
+						h[i] = partner[h[i].Index()]
+					}
+				}
+			}
+			for i, h := range players {
+				vals[i] = game.EvaluateWild(h, board, w)
+			}
+			b.add(vals, 1, tally)
+		}
+	}, s.doneAt(result))
+	res := result(sums, sims)
+	res.Stop = stop
+	return res
+}
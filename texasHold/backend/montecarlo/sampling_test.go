@@ -0,0 +1,189 @@
+package montecarlo
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+
+	"texashold-backend/hand"
+	"texashold-backend/ranges"
+)
+
+func TestParseSampling(t *testing.T) {
+	for _, g := range []Sampling{PlainSampling, StratifiedSampling, AntitheticSampling, ImportanceSampling} {
+		if got, err := ParseSampling(g.String()); err != nil || got != g {
+			t.Errorf("%v: got %v, %v", g, got, err)
+		}
+	}
+	if _, err := ParseSampling("quasi"); err == nil {
+		t.Error("quasi: no error")
+	}
+}
+
+// checkUnbiased runs sample under several seeds and checks that the pooled estimates of
+// every player agree with want, the exact outcomes, within four pooled standard errors.
+func checkUnbiased(t *testing.T, name string, want Result, sampling Sampling, sample func(s Simulator) Result) {
+	t.Helper()
+	const seeds = 6
+	pooled := make([][3]float64, len(want.Players)) // win, tie, equity
+	se2 := make([][3]float64, len(want.Players))
+	ess, sims := 0.0, 0
+	for seed := uint64(1); seed <= seeds; seed++ {
+		res := sample(Simulator{Seed: seed, Sampling: sampling})
+		if res.Sampling != sampling || len(res.Players) != len(want.Players) {
+			t.Fatalf("%s: sampled %v with %d players", name, res.Sampling, len(res.Players))
+		}
+		if res.ESS <= 0 || res.Variance <= 0 || res.Variance != res.equityVariance() {
+			t.Errorf("%s: ESS %v, variance %v", name, res.ESS, res.Variance)
+		}
+		ess += res.ESS
+		sims += res.Sims
+		for i, p := range res.Players {
+			for k, e := range [3]Estimate{p.Win, p.Tie, p.Equity} {
+				pooled[i][k] += e.Value / seeds
+				se2[i][k] += e.StdErr * e.StdErr / (seeds * seeds)
+			}
+		}
+	}
+	for i, p := range want.Players {
+		for k, e := range [3]Estimate{p.Win, p.Tie, p.Equity} {
+			if se := math.Sqrt(se2[i][k]); math.Abs(pooled[i][k]-e.Value) > 4*se+1e-9 {
+				t.Errorf("%s: player %d outcome %d: %v ± %v, exact %v", name, i, k, pooled[i][k], se, e.Value)
+			}
+		}
+	}
+	t.Logf("%s %v: effective sample size %.0f of %d runouts", name, sampling, ess, sims)
+}
+
+func TestSamplingUnbiased(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name      string
+		game      hand.Variant
+		holes     string
+		community string
+	}{
+		// Preflop strata are flops; short deck keeps the enumeration small.
+		{"short deck preflop", hand.ShortDeckHoldem, "HA HK|S9 D9|CT CJ", ""},
+		{"flop", hand.Holdem, "HA HK|S9 D9|DQ DJ", "H9 H5 C2"},
+		{"turn", hand.Holdem, "HA H2|SK DK", "H9 H5 C2 DJ"},
+	}
+	for _, tt := range tests {
+		var holes [][]hand.Card
+		for _, h := range strings.Split(tt.holes, "|") {
+			holes = append(holes, cards(t, h))
+		}
+		community := cards(t, tt.community)
+		exact, err := ExactWinProbabilityMulti(ctx, tt.game, holes, community, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sampling := range []Sampling{StratifiedSampling, AntitheticSampling} {
+			checkUnbiased(t, tt.name, exact, sampling, func(s Simulator) Result {
+				return s.WinProbabilityMulti(ctx, tt.game, hand.Wilds{}, holes, community, 20000)
+			})
+		}
+	}
+
+	// Against random opponents their hands are dealt, or mirrored, along with the board.
+	for _, tt := range []struct {
+		name      string
+		community string
+		players   int
+	}{{"random opponent on the flop", "H9 H5 C2", 2}, {"random opponent on the turn", "H9 H5 C2 DJ", 2}} {
+		hole, community := cards(t, "HA H2"), cards(t, tt.community)
+		exact, err := ExactWinProbability(ctx, hand.Holdem, hole, community, tt.players, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, sampling := range []Sampling{StratifiedSampling, AntitheticSampling} {
+			checkUnbiased(t, tt.name, exact, sampling, func(s Simulator) Result {
+				return s.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, community, tt.players, 20000)
+			})
+		}
+	}
+
+	// Overlapping ranges on the turn: weigh every pair of combos that fit together by the
+	// product of their weights, each enumerated over the rivers.
+	board := cards(t, "H9 H5 C2 DJ")
+	hero := cards(t, "HA HK")
+	rangeA, rangeB := ranges.MustParse("QQ+, AKs, T9s"), ranges.MustParse("JJ+:0.5, AQs, 99")
+	blocked := hand.NewCardSet(board...).Union(hand.NewCardSet(hero...))
+	want := Result{Players: make([]PlayerEquity, 3)}
+	var sums [3][3]float64
+	total := 0.0
+	for _, a := range rangeA.Without(blocked).Combos() {
+		for _, b := range rangeB.Without(blocked | a.CardSet()).Combos() {
+			wt := a.Weight * b.Weight
+			res, err := ExactWinProbabilityMulti(ctx, hand.Holdem, [][]hand.Card{hero, a.Cards(), b.Cards()}, board, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, p := range res.Players {
+				sums[i][0] += wt * p.Win.Value
+				sums[i][1] += wt * p.Tie.Value
+				sums[i][2] += wt * p.Equity.Value
+			}
+			total += wt
+		}
+	}
+	for i := range want.Players {
+		want.Players[i] = PlayerEquity{
+			Win:    exactEstimate(sums[i][0] / total),
+			Tie:    exactEstimate(sums[i][1] / total),
+			Equity: exactEstimate(sums[i][2] / total),
+		}
+	}
+	players := []Player{{Hole: hero}, {Range: rangeA}, {Range: rangeB}}
+	checkUnbiased(t, "ranges", want, ImportanceSampling, func(s Simulator) Result {
+		res, err := s.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, board, 20000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	})
+}
+
+func TestSamplingReducesVariance(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		sampling  Sampling
+		holes     [][]hand.Card
+		community []hand.Card
+	}{
+		// The turn splits into strata by the river's rank.
+		{StratifiedSampling, [][]hand.Card{cards(t, "HA H2"), cards(t, "SK DK")}, cards(t, "H9 H5 C2 DJ")},
+		// Overcards against a small pair preflop: the mirror of a high board is a low one.
+		{AntitheticSampling, [][]hand.Card{cards(t, "HA HK"), cards(t, "S2 D2")}, nil},
+	}
+	for _, tt := range tests {
+		plain := Simulator{Seed: 2}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, tt.holes, tt.community, 100000)
+		if plain.Sampling != PlainSampling || plain.ESS != 100000 {
+			t.Errorf("plain: %v, ESS %v", plain.Sampling, plain.ESS)
+		}
+		res := Simulator{Seed: 2, Sampling: tt.sampling}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, tt.holes, tt.community, 100000)
+		if res.Variance >= plain.Variance || res.ESS <= float64(res.Sims) {
+			t.Errorf("%v: variance %v (plain %v), ESS %v of %d", tt.sampling, res.Variance, plain.Variance, res.ESS, res.Sims)
+		}
+	}
+
+	// Strategies that do not apply sample plainly, and say so.
+	holes := tests[0].holes
+	river := cards(t, "H9 H5 C2 DJ S3")
+	if res := (Simulator{Seed: 2, Sampling: StratifiedSampling}).WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, river, 1000); res.Sampling != PlainSampling {
+		t.Errorf("river: sampled %v", res.Sampling)
+	}
+	players := []Player{{Hole: holes[0]}, {Hole: holes[1]}}
+	if res, err := (Simulator{Seed: 2, Sampling: ImportanceSampling}).RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, 1000); err != nil || res.Sampling != PlainSampling {
+		t.Errorf("no ranges: sampled %v, %v", res.Sampling, err)
+	}
+
+	// Importance sampling deals straight from the combos left, however little of the
+	// range's weight they hold: AsAh leaves only the kings, a billionth of the weight.
+	players = []Player{{Range: ranges.MustParse("AsAh")}, {Range: ranges.MustParse("AsAh, KK:0.000000001")}}
+	res, err := Simulator{Seed: 2, Sampling: ImportanceSampling}.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, 100000)
+	if err != nil || res.Stop != StopCompleted || res.Sims != 100000 || math.Abs(res.Players[0].Equity.Value-0.82) > 0.02 {
+		t.Errorf("unlikely fit: %v after %d sims, %+v, %v", res.Stop, res.Sims, res.Players[0].Equity, err)
+	}
+}
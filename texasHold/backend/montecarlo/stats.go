@@ -0,0 +1,172 @@
+package montecarlo
+
+import "math"
+
+// z95 is the normal quantile of a two-sided 95% confidence interval.
+const z95 = 1.959964
+
+// Estimate is a simulated probability or share with its standard error and 95%
+// confidence interval (normal approximation, clipped to [0, 1]).
+type Estimate struct {
+	Value  float64
+	StdErr float64
+	Low    float64
+	High   float64
+}
+
+// Margin returns the half-width of the 95% confidence interval.
+func (e Estimate) Margin() float64 {
+	return z95 * e.StdErr
+}
+
+// estimate returns the Estimate of the mean of n samples with the given sum and sum of
+// squares. For 0/1 outcomes sumSq equals sum.
+func estimate(sum, sumSq float64, n int) Estimate {
+	if n == 0 {
+		return Estimate{}
+	}
+	nf := float64(n)
+	mean := sum / nf
+	var se float64
+	if n > 1 {
+		variance := (sumSq - nf*mean*mean) / (nf - 1)
+		se = math.Sqrt(math.Max(variance, 0) / nf)
+	}
+	return NewEstimate(mean, se)
+}
+
+// NewEstimate returns the Estimate of a value with standard error se, e.g. one stored
+// in a precomputed table.
+func NewEstimate(v, se float64) Estimate {
+	return Estimate{
+		Value:  v,
+		StdErr: se,
+		Low:    math.Max(0, v-z95*se),
+		High:   math.Min(1, v+z95*se),
+	}
+}
+
+// exactEstimate is the Estimate of a value known exactly.
+func exactEstimate(v float64) Estimate {
+	return Estimate{Value: v, Low: v, High: v}
+}
+
+// StopReason says why a simulation run ended.
+type StopReason string
+
+const (
+	StopCompleted    StopReason = "completed"     // ran all the simulations asked for
+	StopTargetMargin StopReason = "target_margin" // every confidence interval got narrow enough
+	StopDeadline     StopReason = "max_duration"  // the context's deadline passed
+	StopCancelled    StopReason = "cancelled"     // the context was cancelled, e.g. the client left
+)
+
+// PlayerEquity is one player's simulated outcome: outright wins, ties for the best hand
+// and equity (the average share of the pot, a k-way tie winning 1/k). A player ties when
+// sharing the best hand with any other player, even one of several opponents.
+type PlayerEquity struct {
+	Win    Estimate
+	Tie    Estimate
+	Equity Estimate
+}
+
+// Result is the outcome of a simulation run: one PlayerEquity per player (only ours
+// for WinProbability), how many simulations ran and why the run stopped. HandTypes has
+// the players' made-hand distributions when they were collected.
+//
+// A simulated Result also reports its precision: Variance is the largest variance of an
+// equity estimate (its StdErr squared), and ESS the effective sample size, the plain
+// simulations that would give the same variance (Sims itself under plain sampling).
+type Result struct {
+	Players   []PlayerEquity
+	HandTypes []HandTypeStats
+	Sims      int
+	Stop      StopReason
+	Sampling  Sampling
+	ESS       float64
+	Variance  float64
+}
+
+// Margin returns the widest 95% confidence half-width in r.
+func (r Result) Margin() float64 {
+	m := 0.0
+	for _, p := range r.Players {
+		m = max(m, p.Win.Margin(), p.Tie.Margin(), p.Equity.Margin())
+	}
+	return m
+}
+
+// equityVariance returns the largest variance of a player's equity estimate.
+func (r Result) equityVariance() float64 {
+	v := 0.0
+	for _, p := range r.Players {
+		v = max(v, p.Equity.StdErr*p.Equity.StdErr)
+	}
+	return v
+}
+
+// fractions returns each player's win and tie values, nil for a Result without players.
+func (r Result) fractions() (win, tie []float64) {
+	if len(r.Players) == 0 {
+		return nil, nil
+	}
+	win, tie = make([]float64, len(r.Players)), make([]float64, len(r.Players))
+	for i, p := range r.Players {
+		win[i], tie[i] = p.Win.Value, p.Tie.Value
+	}
+	return win, tie
+}
+
+// showdownSlots is the width of one player's outcome in a showdown tally: wins, ties
+// (sharing the best hand), equity and equity squared.
+const showdownSlots = 4
+
+// tallyShowdown adds the showdown of the players' scores vals (hand.Strength, or a low
+// for lowball games; higher wins) to tally, showdownSlots per player. Only the players
+// sharing the best score tie, and each of them gets an equal share of the pot.
+func tallyShowdown[S ~uint16 | ~uint32](vals []S, tally []float64) {
+	best, nBest := bestOf(vals)
+	share := 1 / float64(nBest)
+	for i, v := range vals {
+		if v != best {
+			continue
+		}
+		t := tally[i*showdownSlots:]
+		if nBest == 1 {
+			t[0]++
+		} else {
+			t[1]++
+		}
+		t[2] += share
+		t[3] += share * share
+	}
+}
+
+// bestOf returns the best (highest) score in vals and how many players share it.
+func bestOf[S ~uint16 | ~uint32](vals []S) (best S, n int) {
+	best = vals[0]
+	for _, v := range vals {
+		if v > best {
+			best, n = v, 0
+		}
+		if v == best {
+			n++
+		}
+	}
+	return best, n
+}
+
+// showdownResult turns a showdown tally of n players over sims simulations into a Result.
+func showdownResult(sums []float64, sims, n int) Result {
+	res := Result{Players: make([]PlayerEquity, n), Sims: sims}
+	for i := range res.Players {
+		t := sums[i*showdownSlots:]
+		res.Players[i] = PlayerEquity{
+			Win:    estimate(t[0], t[0], sims),
+			Tie:    estimate(t[1], t[1], sims),
+			Equity: estimate(t[2], t[3], sims),
+		}
+	}
+	res.ESS, res.Variance = float64(sims), res.equityVariance()
+	return res
+}
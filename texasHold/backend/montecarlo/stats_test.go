@@ -0,0 +1,198 @@
+package montecarlo
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"texashold-backend/hand"
+)
+
+func TestEstimate(t *testing.T) {
+	// 30 wins in 100: p = 0.3, sample variance 0.3*0.7*100/99, SE = sqrt(0.3*0.7/99).
+	e := estimate(30, 30, 100)
+	se := math.Sqrt(0.3 * 0.7 / 99)
+	if e.Value != 0.3 || math.Abs(e.StdErr-se) > 1e-12 || math.Abs(e.High-e.Low-2*z95*se) > 1e-12 {
+		t.Errorf("estimate(30, 30, 100) = %+v", e)
+	}
+	if e := estimate(0, 0, 100); e.StdErr != 0 || e.Low != 0 || e.High != 0 {
+		t.Errorf("estimate(0, 0, 100) = %+v", e)
+	}
+	if e := estimate(99, 99, 100); e.High > 1 {
+		t.Errorf("interval above 1: %+v", e)
+	}
+}
+
+func TestConfidenceIntervals(t *testing.T) {
+	ctx := context.Background()
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9"), cards(t, "DQ DJ")}
+	board := cards(t, "H9 H5 C2")
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := Simulator{Seed: 1}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, 20000)
+	if res.Sims != 20000 || res.Stop != StopCompleted {
+		t.Errorf("ran %d sims, stop %q", res.Sims, res.Stop)
+	}
+	equity := 0.0
+	for i, p := range res.Players {
+		want := exact.Players[i]
+		for _, c := range []struct {
+			name string
+			got  Estimate
+			want float64
+		}{{"win", p.Win, want.Win.Value}, {"tie", p.Tie, want.Tie.Value}, {"equity", p.Equity, want.Equity.Value}} {
+			// Within 3 standard errors, and the interval is about as wide as it should be.
+			if math.Abs(c.got.Value-c.want) > 3*c.got.StdErr+1e-9 || c.got.Margin() > 0.01 {
+				t.Errorf("player %d %s: %+v, exact %v", i, c.name, c.got, c.want)
+			}
+		}
+		equity += p.Equity.Value
+	}
+	if math.Abs(equity-1) > 1e-9 {
+		t.Errorf("equities sum to %v", equity)
+	}
+
+	// Hi/lo reports its scoops, splits and equity with intervals too.
+	hiLo := [][]hand.Card{cards(t, "HA H2 S3 SK"), cards(t, "SQ DQ HJ DJ")}
+	flop := cards(t, "D4 C5 D8")
+	exactHiLo, err := ExactWinProbabilityMultiHiLo(ctx, hand.OmahaHiLo, hiLo, flop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled, _, _ := Simulator{Seed: 1}.WinProbabilityMultiHiLo(ctx, hand.OmahaHiLo, hiLo, flop, 20000)
+	for i, p := range sampled {
+		want := exactHiLo[i]
+		if e := want.Stats.Equity; e.Value != want.Equity || e.Low != e.Value || e.High != e.Value {
+			t.Errorf("exact hi/lo player %d: %+v", i, want.Stats)
+		}
+		for _, c := range []struct {
+			name  string
+			got   Estimate
+			value float64
+			exact float64
+		}{{"scoop", p.Stats.Win, p.Scoop, want.Scoop}, {"split", p.Stats.Tie, p.Split, want.Split}, {"equity", p.Stats.Equity, p.Equity, want.Equity}} {
+			if c.got.Value != c.value || math.Abs(c.got.Value-c.exact) > 3*c.got.StdErr+1e-9 {
+				t.Errorf("hi/lo player %d %s: %+v, exact %v", i, c.name, c.got, c.exact)
+			}
+		}
+		if p.Stats.Equity.StdErr == 0 {
+			t.Errorf("hi/lo player %d: equity without an interval", i)
+		}
+	}
+}
+
+func TestAdaptiveStopping(t *testing.T) {
+	ctx := context.Background()
+	hole := cards(t, "HA HK")
+	const nSims = 500000
+
+	// A target margin stops at a round boundary, the same one for any number of workers.
+	var first Result
+	for _, workers := range []int{1, 4} {
+		res := Simulator{Seed: 3, Workers: workers, TargetMargin: 0.01}.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, nil, 3, nSims)
+		if res.Stop != StopTargetMargin || res.Sims >= nSims || res.Sims%(roundChunks*chunkSims) != 0 || res.Margin() > 0.01 {
+			t.Errorf("%d workers: stopped after %d sims (%q), margin %v", workers, res.Sims, res.Stop, res.Margin())
+		}
+		if workers == 1 {
+			first = res
+		} else if !reflect.DeepEqual(res, first) {
+			t.Errorf("%d workers: %+v, want %+v", workers, res, first)
+		}
+	}
+
+	// A deadline stops the run with what it has.
+	timed, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	res := Simulator{Seed: 3}.WinProbability(timed, hand.Holdem, hand.Wilds{}, hole, nil, 9, nSims)
+	if res.Stop != StopDeadline || res.Sims == 0 || res.Sims >= nSims || len(res.Players) != 1 {
+		t.Errorf("deadline: stopped after %d sims (%q)", res.Sims, res.Stop)
+	}
+
+	// A cancelled context still runs the first chunk.
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	res = Simulator{Seed: 3}.WinProbabilityMulti(cancelled, hand.Holdem, hand.Wilds{}, [][]hand.Card{hole, cards(t, "S9 D9")}, nil, nSims)
+	if res.Stop != StopCancelled || res.Sims != chunkSims {
+		t.Errorf("cancelled: stopped after %d sims (%q)", res.Sims, res.Stop)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	ctx := context.Background()
+	holes := [][]hand.Card{cards(t, "HA HK"), cards(t, "S9 D9")}
+	const nSims = 100000
+	round := roundChunks * chunkSims
+
+	// Progress sees every round but the last and leaves the result unchanged.
+	var seen []Result
+	sim := Simulator{Seed: 5, Progress: func(res Result) { seen = append(seen, res) }}
+	res := sim.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, nil, nSims)
+	if len(seen) != nSims/round {
+		t.Fatalf("%d progress calls, want %d", len(seen), nSims/round)
+	}
+	for i, p := range seen {
+		if p.Sims != (i+1)*round || len(p.Players) != 2 || p.Margin() <= res.Margin() {
+			t.Errorf("call %d: %d sims, margin %v", i, p.Sims, p.Margin())
+		}
+	}
+	if want := (Simulator{Seed: 5}).WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, nil, nSims); !reflect.DeepEqual(res, want) {
+		t.Errorf("with progress %+v, want %+v", res, want)
+	}
+
+	// Cancelling from Progress, as a client going away does, ends the run there.
+	cancelled, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sim.Progress = func(Result) { cancel() }
+	res = sim.WinProbabilityMulti(cancelled, hand.Holdem, hand.Wilds{}, holes, nil, nSims)
+	if res.Stop != StopCancelled || res.Sims != round {
+		t.Errorf("cancelled: stopped after %d sims (%q)", res.Sims, res.Stop)
+	}
+}
+
+func TestSplitPots(t *testing.T) {
+	ctx := context.Background()
+	// Players 0 and 1 chop with the same straight; 2 and 3 lose and have not tied.
+	holes := [][]hand.Card{cards(t, "HJ HT"), cards(t, "DJ DT"), cards(t, "H9 H8"), cards(t, "C9 C8")}
+	board := cards(t, "SA SK SQ D3 C2")
+	exact, err := ExactWinProbabilityMulti(ctx, hand.Holdem, holes, board, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampled := Simulator{Seed: 1}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, 1000)
+	wantTie, wantEquity := []float64{1, 1, 0, 0}, []float64{0.5, 0.5, 0, 0}
+	for _, res := range []Result{exact, sampled} {
+		for i, p := range res.Players {
+			if p.Win.Value != 0 || p.Tie.Value != wantTie[i] || p.Equity.Value != wantEquity[i] {
+				t.Errorf("player %d: %+v", i, p)
+			}
+		}
+	}
+
+	// A three-way chop credits a third each.
+	holes = [][]hand.Card{cards(t, "HJ HT"), cards(t, "DJ DT"), cards(t, "CJ CT")}
+	res := Simulator{Seed: 1}.WinProbabilityMulti(ctx, hand.Holdem, hand.Wilds{}, holes, board, 1000)
+	for i, p := range res.Players {
+		if p.Tie.Value != 1 || math.Abs(p.Equity.Value-1.0/3) > 1e-12 {
+			t.Errorf("three-way chop, player %d: %+v", i, p)
+		}
+	}
+
+	// Multiway on the flop: equities sum to 1, and the wins plus the split pots too.
+	holes = [][]hand.Card{cards(t, "HA HK"), cards(t, "SA SK"), cards(t, "D9 C9"), cards(t, "H5 S5")}
+	exact, err = ExactWinProbabilityMulti(ctx, hand.Holdem, holes, cards(t, "DA DK C2"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var win, equity float64
+	for _, p := range exact.Players {
+		win += p.Win.Value
+		equity += p.Equity.Value
+	}
+	if math.Abs(equity-1) > 1e-9 || exact.Players[0].Tie.Value == exact.Players[2].Tie.Value || win >= 1 {
+		t.Errorf("flop: win %v equity %v, %+v", win, equity, exact.Players)
+	}
+}
@@ -0,0 +1,72 @@
+package montecarlo
+
+import (
+	"context"
+	"math/rand/v2"
+
+	"texashold-backend/hand"
+)
+
+// StudWinProbability runs nSims of a stud game (hand.SevenCardStud or hand.Razz). Each
+// player's known down and up cards are fixed, and the cards they still need to reach
+// seven are dealt from a deck without the known cards and the dead cards (e.g. folded
+// upcards). Razz awards the pot to the best ace-to-five low. Returns per-player win and
+// tie fractions, as WinProbabilityMulti. Stud's common card (when the deck runs out) is
+// not modeled: all players' seven cards must fit in the deck.
+func StudWinProbability(game hand.Variant, players []hand.StudHand, dead []hand.Card, nSims int) (winFracs, tieFracs []float64) {
+	return defaultSimulator().StudWinProbability(context.Background(), game, players, dead, nSims).fractions()
+}
+
+// StudWinProbability is the package's StudWinProbability run by s, reporting each
+// player's win, tie and equity as Simulator.WinProbabilityMulti does and stopping early
+// when ctx is done. The Result has no players for invalid input.
+func (s Simulator) StudWinProbability(ctx context.Context, game hand.Variant, players []hand.StudHand, dead []hand.Card, nSims int) Result {
+	nPlayers := len(players)
+	if nPlayers < 2 || nSims <= 0 || !game.Stud() {
+		return Result{}
+	}
+	known := hand.NewCardSet(dead...)
+	nKnown := len(dead)
+	toDeal := 0
+	for _, p := range players {
+		if p.Missing() < 0 {
+			return Result{}
+		}
+		known = known.Union(hand.NewCardSet(p.Known()...))
+		nKnown += len(p.Known())
+		toDeal += p.Missing()
+	}
+	if known.Count() != nKnown {
+		return Result{} // duplicate cards
+	}
+	if game.Rules().NewDeck(known).Remaining() < toDeal {
+		return Result{}
+	}
+	sums, sims, stop := s.run(ctx, nSims, nPlayers*showdownSlots, func() trial {
+		deck := game.Rules().NewDeck(known)
+		cards := make([][]hand.Card, nPlayers)
+		for i, p := range players {
+			cards[i] = append(make([]hand.Card, 0, 7), p.Known()...)
+		}
+		scores := make([]uint32, nPlayers)
+		return func(r *rand.Rand, tally []float64) {
+			deck.Shuffle(r)
+			for i, p := range players {
+				c := cards[i][:7-p.Missing()]
+				for len(c) < 7 {
+					c = append(c, deck.Deal())
+				}
+				cards[i] = c
+				if game.Lowball() {
+					scores[i] = uint32(game.EvaluateLow(c, nil))
+				} else {
+					scores[i] = uint32(game.Evaluate(c, nil))
+				}
+			}
+			tallyShowdown(scores, tally)
+		}
+	}, nil)
+	res := showdownResult(sums, sims, nPlayers)
+	res.Stop = stop
+	return res
+}
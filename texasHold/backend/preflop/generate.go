@@ -0,0 +1,64 @@
+package preflop
+
+import (
+	"context"
+
+	"texashold-backend/hand"
+	"texashold-backend/montecarlo"
+	"texashold-backend/ranges"
+)
+
+// Generate computes the tables by simulation: randomSims simulations for each class and
+// number of random opponents, headsUpSims for each pair of classes. Every entry is
+// seeded with seed, so the same arguments give the same tables. progress, when not nil,
+// is called after each entry with the entries done and their total. Generate stops with
+// ctx.Err() when ctx is done first.
+func Generate(ctx context.Context, seed uint64, randomSims, headsUpSims int, progress func(done, total int)) (*Tables, error) {
+	t := &Tables{f: file{Magic: magic, RandomSims: uint32(randomSims), HeadsUpSims: uint32(headsUpSims)}}
+	classes := ranges.Classes()
+	sim := montecarlo.Simulator{Seed: seed}
+	done, total := 0, NumClasses*MaxOpponents+NumClasses*(NumClasses+1)/2
+	step := func() error {
+		done++
+		if progress != nil {
+			progress(done, total)
+		}
+		return ctx.Err()
+	}
+
+	for i, c := range classes {
+		hole := c.Combos()[0].Cards()
+		for k := range t.f.Random[i] {
+			res := sim.WinProbability(ctx, hand.Holdem, hand.Wilds{}, hole, nil, k+2, randomSims)
+			t.f.Random[i][k] = newRecord(res.Players[0])
+			if err := step(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for i, c := range classes {
+		for j := i; j < NumClasses; j++ {
+			players := []montecarlo.Player{{Range: classRange(c)}, {Range: classRange(classes[j])}}
+			res, err := sim.RangeEquity(ctx, hand.Holdem, hand.Wilds{}, players, nil, headsUpSims)
+			if err != nil {
+				return nil, err
+			}
+			t.f.HeadsUp[i][j] = newRecord(res.Players[0])
+			t.f.HeadsUp[j][i] = newRecord(res.Players[1])
+			if err := step(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// classRange returns the range of every combo of c.
+func classRange(c ranges.Class) *ranges.Range {
+	r := ranges.New()
+	for _, combo := range c.Combos() {
+		r.Set(combo, 1)
+	}
+	return r
+}
@@ -0,0 +1,155 @@
+// Package preflop answers Hold'em preflop equities from tables computed offline and
+// embedded in the binary: each of the 169 starting-hand classes against 1 to
+// MaxOpponents random hands, and every class heads-up against every class. Suits do not
+// matter before the flop, so a class stands for each of its combos against random
+// hands. cmd/preflopgen builds the tables; rerun it after changing the evaluator.
+package preflop
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"sync"
+
+	"texashold-backend/montecarlo"
+	"texashold-backend/ranges"
+)
+
+// MaxOpponents is the most random opponents the tables cover.
+const MaxOpponents = 9
+
+// NumClasses is the number of starting-hand classes.
+const NumClasses = 169
+
+//go:embed tables.bin
+var embedded []byte
+
+var magic = [4]byte{'P', 'F', 'T', '1'}
+
+// ErrBadTables is returned by Read for data that is not a tables file.
+var ErrBadTables = errors.New("preflop: not a preflop tables file")
+
+// Tables holds the equities of every class, indexed as in ranges.Classes.
+type Tables struct {
+	f file
+}
+
+// file is the tables file: a header, then the entries against random hands by class
+// and opponents-1, then the heads-up entries by class and opposing class, all
+// little-endian.
+type file struct {
+	Magic                   [4]byte
+	RandomSims, HeadsUpSims uint32
+	Random                  [NumClasses][MaxOpponents]record
+	HeadsUp                 [NumClasses][NumClasses]record
+}
+
+// record is a PlayerEquity in fixed point: values and standard errors in units of 1/65535.
+type record struct {
+	Win, Tie, Equity, WinErr, TieErr, EquityErr uint16
+}
+
+func fixed(v float64) uint16 {
+	return uint16(math.Round(math.Min(math.Max(v, 0), 1) * math.MaxUint16))
+}
+
+func newRecord(p montecarlo.PlayerEquity) record {
+	return record{
+		Win: fixed(p.Win.Value), Tie: fixed(p.Tie.Value), Equity: fixed(p.Equity.Value),
+		WinErr: fixed(p.Win.StdErr), TieErr: fixed(p.Tie.StdErr), EquityErr: fixed(p.Equity.StdErr),
+	}
+}
+
+func (r record) equity() montecarlo.PlayerEquity {
+	est := func(v, se uint16) montecarlo.Estimate {
+		return montecarlo.NewEstimate(float64(v)/math.MaxUint16, float64(se)/math.MaxUint16)
+	}
+	return montecarlo.PlayerEquity{Win: est(r.Win, r.WinErr), Tie: est(r.Tie, r.TieErr), Equity: est(r.Equity, r.EquityErr)}
+}
+
+// RandomSims returns the simulations behind each entry against random hands.
+func (t *Tables) RandomSims() int {
+	return int(t.f.RandomSims)
+}
+
+// HeadsUpSims returns the simulations behind each heads-up entry.
+func (t *Tables) HeadsUpSims() int {
+	return int(t.f.HeadsUpSims)
+}
+
+// VsRandom returns the outcome of class c against opponents random hands, and false
+// when opponents is not 1 to MaxOpponents.
+func (t *Tables) VsRandom(c ranges.Class, opponents int) (montecarlo.PlayerEquity, bool) {
+	if opponents < 1 || opponents > MaxOpponents {
+		return montecarlo.PlayerEquity{}, false
+	}
+	return t.f.Random[Index(c)][opponents-1].equity(), true
+}
+
+// HeadsUp returns the outcome of class c against class vs, each dealt as one of its
+// combos at random (the two never share a card).
+func (t *Tables) HeadsUp(c, vs ranges.Class) montecarlo.PlayerEquity {
+	return t.f.HeadsUp[Index(c)][Index(vs)].equity()
+}
+
+// Read reads tables written by Write.
+func Read(r io.Reader) (*Tables, error) {
+	t := new(Tables)
+	if err := binary.Read(r, binary.LittleEndian, &t.f); err != nil || t.f.Magic != magic {
+		return nil, ErrBadTables
+	}
+	return t, nil
+}
+
+// Write writes the tables in the format Read reads and the binary embeds.
+func (t *Tables) Write(w io.Writer) error {
+	return binary.Write(w, binary.LittleEndian, &t.f)
+}
+
+var embeddedTables = sync.OnceValue(func() *Tables {
+	t, err := Read(bytes.NewReader(embedded))
+	if err != nil {
+		panic(err) // tables.bin is built with the binary; the package tests read it
+	}
+	return t
+})
+
+// Embedded returns the tables built into the binary.
+func Embedded() *Tables {
+	return embeddedTables()
+}
+
+var classIndex = func() map[ranges.Class]int {
+	m := make(map[ranges.Class]int, NumClasses)
+	for i, c := range ranges.Classes() {
+		m[c] = i
+	}
+	return m
+}()
+
+// Index returns the position of c in ranges.Classes, the row of c in the tables.
+func Index(c ranges.Class) int {
+	return classIndex[c]
+}
+
+// ClassOf reports whether r is exactly one whole class, every combo at full weight
+// (such as "AKs" or "TT"), and returns it.
+func ClassOf(r *ranges.Range) (ranges.Class, bool) {
+	combos := r.Combos()
+	if len(combos) == 0 {
+		return ranges.Class{}, false
+	}
+	c := combos[0].Class()
+	if len(combos) != len(c.Combos()) {
+		return ranges.Class{}, false
+	}
+	for _, wc := range combos {
+		if wc.Weight != 1 || wc.Class() != c {
+			return ranges.Class{}, false
+		}
+	}
+	return c, true
+}
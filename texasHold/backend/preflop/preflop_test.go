@@ -0,0 +1,94 @@
+package preflop
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"texashold-backend/ranges"
+)
+
+func class(t *testing.T, s string) ranges.Class {
+	t.Helper()
+	c, ok := ClassOf(ranges.MustParse(s))
+	if !ok {
+		t.Fatalf("%s is not a class", s)
+	}
+	return c
+}
+
+func TestEmbedded(t *testing.T) {
+	tables := Embedded()
+	if tables.RandomSims() < 100000 || tables.HeadsUpSims() < 10000 {
+		t.Fatalf("sims %d, %d", tables.RandomSims(), tables.HeadsUpSims())
+	}
+	// Well-known equities.
+	for _, c := range []struct {
+		hand      string
+		opponents int
+		want      float64
+	}{{"AA", 1, 0.852}, {"72o", 1, 0.346}, {"AKs", 1, 0.670}, {"AA", 9, 0.310}} {
+		p, ok := tables.VsRandom(class(t, c.hand), c.opponents)
+		if !ok || math.Abs(p.Equity.Value-c.want) > 0.005 || p.Equity.StdErr == 0 || p.Equity.StdErr > 0.001 {
+			t.Errorf("%s against %d: %+v, want equity %v", c.hand, c.opponents, p.Equity, c.want)
+		}
+	}
+	for _, c := range ranges.Classes() {
+		prev := 1.0
+		for k := 1; k <= MaxOpponents; k++ {
+			p, _ := tables.VsRandom(c, k)
+			if p.Equity.Value >= prev || p.Win.Value+p.Tie.Value < p.Equity.Value-1e-4 {
+				t.Errorf("%s against %d: %+v", c, k, p)
+			}
+			prev = p.Equity.Value
+		}
+	}
+	if _, ok := tables.VsRandom(class(t, "AA"), MaxOpponents+1); ok {
+		t.Error("10 opponents")
+	}
+
+	// Heads-up, both ways round.
+	if eq := tables.HeadsUp(class(t, "AA"), class(t, "KK")).Equity.Value; math.Abs(eq-0.82) > 0.01 {
+		t.Errorf("AA against KK: %v", eq)
+	}
+	for _, a := range ranges.Classes() {
+		for _, b := range ranges.Classes() {
+			p, q := tables.HeadsUp(a, b), tables.HeadsUp(b, a)
+			if a == b {
+				if math.Abs(p.Equity.Value-0.5) > 0.002 {
+					t.Errorf("%s against itself: %+v", a, p.Equity)
+				}
+				continue
+			}
+			if math.Abs(p.Equity.Value+q.Equity.Value-1) > 2e-4 || math.Abs(p.Tie.Value-q.Tie.Value) > 2e-4 {
+				t.Fatalf("%s against %s: %+v and %+v", a, b, p, q)
+			}
+		}
+	}
+}
+
+func TestReadWrite(t *testing.T) {
+	var buf bytes.Buffer
+	if err := Embedded().Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), embedded) {
+		t.Error("written tables differ from the embedded file")
+	}
+	if _, err := Read(bytes.NewReader(embedded[:100])); !errors.Is(err, ErrBadTables) {
+		t.Errorf("short file: %v", err)
+	}
+	bad := append([]byte("XXXX"), embedded[4:]...)
+	if _, err := Read(bytes.NewReader(bad)); !errors.Is(err, ErrBadTables) {
+		t.Errorf("bad magic: %v", err)
+	}
+}
+
+func TestClassOf(t *testing.T) {
+	for s, want := range map[string]bool{"AKs": true, "TT": true, "72o": true, "AK": false, "AKs:0.5": false, "AhKh": false, "AKs, KQs": false} {
+		if _, ok := ClassOf(ranges.MustParse(s)); ok != want {
+			t.Errorf("%s: %v", s, ok)
+		}
+	}
+}
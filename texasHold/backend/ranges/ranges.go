@@ -0,0 +1,417 @@
+// Package ranges parses, expands and prints poker hand ranges in the usual notation,
+// e.g. "TT+, A2s-A5s, KQo, AKs:0.5, AhKh".
+package ranges
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"texashold-backend/hand"
+)
+
+var suits = []rune{hand.SuitHeart, hand.SuitSpade, hand.SuitDiamond, hand.SuitClub}
+
+const rankChars = "23456789TJQKA"
+
+// Combo is a concrete pair of hole cards. NewCombo orders the cards so that equal
+// combos compare equal: higher rank first, and for pairs the suit order H, S, D, C.
+type Combo struct {
+	A, B hand.Card
+}
+
+// NewCombo returns the combo of two distinct cards.
+func NewCombo(a, b hand.Card) Combo {
+	if b.Rank > a.Rank || (b.Rank == a.Rank && b.Index() < a.Index()) {
+		a, b = b, a
+	}
+	return Combo{A: a, B: b}
+}
+
+// Cards returns the two cards of c.
+func (c Combo) Cards() []hand.Card {
+	return []hand.Card{c.A, c.B}
+}
+
+// CardSet returns the two cards of c as a set.
+func (c Combo) CardSet() hand.CardSet {
+	return hand.NewCardSet(c.A, c.B)
+}
+
+// Class returns the starting-hand class of c, e.g. AKs for AhKh.
+func (c Combo) Class() Class {
+	return Class{High: c.A.Rank, Low: c.B.Rank, Suited: c.A.Suit == c.B.Suit}
+}
+
+// String returns the combo in rank-first notation, e.g. "AhKh".
+func (c Combo) String() string {
+	return c.A.Format(hand.RankFirst) + c.B.Format(hand.RankFirst)
+}
+
+// Class is one of the 169 starting-hand classes: a pair (High == Low, Suited false),
+// or two ranks High > Low, suited or offsuit.
+type Class struct {
+	High, Low int
+	Suited    bool
+}
+
+// IsPair reports whether c is a pocket pair.
+func (c Class) IsPair() bool {
+	return c.High == c.Low
+}
+
+// String returns the class notation, e.g. "TT", "AKs", "72o".
+func (c Class) String() string {
+	s := string(rankChars[c.High]) + string(rankChars[c.Low])
+	switch {
+	case c.IsPair():
+		return s
+	case c.Suited:
+		return s + "s"
+	default:
+		return s + "o"
+	}
+}
+
+// Combos returns the concrete combos of c: 6 for a pair, 4 suited, 12 offsuit.
+func (c Class) Combos() []Combo {
+	var out []Combo
+	for i, s1 := range suits {
+		for j, s2 := range suits {
+			switch {
+			case c.IsPair() && j <= i, !c.IsPair() && c.Suited != (i == j):
+				continue
+			}
+			out = append(out, NewCombo(hand.Card{Suit: s1, Rank: c.High}, hand.Card{Suit: s2, Rank: c.Low}))
+		}
+	}
+	return out
+}
+
+// Classes returns all 169 classes: pairs from AA down, then suited hands, then offsuit
+// hands, each by high card and then kicker from the top.
+func Classes() []Class {
+	out := make([]Class, 0, 169)
+	for r := hand.RankA; r >= hand.Rank2; r-- {
+		out = append(out, Class{High: r, Low: r})
+	}
+	for _, suited := range []bool{true, false} {
+		for h := hand.RankA; h >= hand.Rank3; h-- {
+			for l := h - 1; l >= hand.Rank2; l-- {
+				out = append(out, Class{High: h, Low: l, Suited: suited})
+			}
+		}
+	}
+	return out
+}
+
+// WeightedCombo is a combo with its weight in a range.
+type WeightedCombo struct {
+	Combo
+	Weight float64
+}
+
+// Range is a set of combos, each with a weight in (0, 1]; a weight of 0.5 means the
+// combo is played half the time. The zero value is not usable; use New or Parse.
+type Range struct {
+	weights map[Combo]float64
+}
+
+// New returns an empty range.
+func New() *Range {
+	return &Range{weights: make(map[Combo]float64)}
+}
+
+// Set sets the weight of c; a weight of 0 or less removes it.
+func (r *Range) Set(c Combo, w float64) {
+	if w <= 0 {
+		delete(r.weights, c)
+		return
+	}
+	r.weights[c] = w
+}
+
+// Weight returns the weight of c (0 if c is not in the range).
+func (r *Range) Weight(c Combo) float64 {
+	return r.weights[c]
+}
+
+// Len returns the number of combos in the range.
+func (r *Range) Len() int {
+	return len(r.weights)
+}
+
+// TotalWeight returns the sum of all combo weights.
+func (r *Range) TotalWeight() float64 {
+	var sum float64
+	for _, w := range r.weights {
+		sum += w
+	}
+	return sum
+}
+
+// Combos returns the combos of the range in class order (see Classes), then by suits.
+func (r *Range) Combos() []WeightedCombo {
+	out := make([]WeightedCombo, 0, len(r.weights))
+	for _, cl := range Classes() {
+		for _, c := range cl.Combos() {
+			if w, ok := r.weights[c]; ok {
+				out = append(out, WeightedCombo{Combo: c, Weight: w})
+			}
+		}
+	}
+	return out
+}
+
+// Without returns a copy of r without the combos that use any of the dead cards
+// (card removal, e.g. our hole cards and the board block combos of an opponent).
+func (r *Range) Without(dead hand.CardSet) *Range {
+	out := New()
+	for c, w := range r.weights {
+		if c.CardSet().Intersect(dead) == 0 {
+			out.weights[c] = w
+		}
+	}
+	return out
+}
+
+// Parse parses a range: tokens separated by commas or spaces, each one of
+//
+//	AA, TT+, 22-55          pairs: one, TT up to AA, or all between two pairs
+//	AKs, AKo, AK            suited, offsuit or both
+//	ATs+, KTo+, QT+         the kicker up to one below the high card
+//	A2s-A5s, KTo-KQo        all kickers between two hands with the same high card
+//	AhKh, 10sTd             a specific combo (any notation ParseCard reads)
+//
+// optionally followed by ":weight" with a weight in (0, 1], e.g. "AKs:0.5". A later
+// token overrides the weight set by an earlier one.
+func Parse(s string) (*Range, error) {
+	r := New()
+	tokens := strings.FieldsFunc(s, func(c rune) bool { return c == ',' || c == ' ' || c == '\t' || c == '\n' })
+	for _, tok := range tokens {
+		if err := r.addToken(tok); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// MustParse is Parse that panics on error, for ranges written in code.
+func MustParse(s string) *Range {
+	r, err := Parse(s)
+	if err != nil {
+		panic(err)
+	}
+	return r
+}
+
+func (r *Range) addToken(tok string) error {
+	body, w := tok, 1.0
+	if i := strings.LastIndexByte(tok, ':'); i >= 0 {
+		body = tok[:i]
+		var err error
+		w, err = strconv.ParseFloat(tok[i+1:], 64)
+		if err != nil || w <= 0 || w > 1 {
+			return fmt.Errorf("invalid weight in %q: must be in (0, 1]", tok)
+		}
+	}
+	classes, err := parseClasses(body)
+	if err != nil {
+		cards, cerr := hand.ParseCards(body)
+		if cerr != nil || len(cards) != 2 || cards[0] == cards[1] {
+			return fmt.Errorf("invalid range token: %q", tok)
+		}
+		r.Set(NewCombo(cards[0], cards[1]), w)
+		return nil
+	}
+	for _, cl := range classes {
+		for _, c := range cl.Combos() {
+			r.Set(c, w)
+		}
+	}
+	return nil
+}
+
+// parseClasses expands a class token (no weight) such as "TT+", "A2s-A5s" or "AK".
+func parseClasses(tok string) ([]Class, error) {
+	if lo, hi, ok := strings.Cut(tok, "-"); ok {
+		a, sa, err := parseClassSpec(lo)
+		if err != nil {
+			return nil, err
+		}
+		b, sb, err := parseClassSpec(hi)
+		if err != nil {
+			return nil, err
+		}
+		if sa != sb || a.IsPair() != b.IsPair() || (!a.IsPair() && a.High != b.High) {
+			return nil, fmt.Errorf("invalid span: %q", tok)
+		}
+		if a.Low > b.Low {
+			a, b = b, a
+		}
+		var out []Class
+		for l := a.Low; l <= b.Low; l++ {
+			if a.IsPair() {
+				out = append(out, Class{High: l, Low: l})
+			} else {
+				out = appendSuits(out, Class{High: a.High, Low: l}, sa)
+			}
+		}
+		return out, nil
+	}
+	plus := strings.HasSuffix(tok, "+")
+	cl, suits, err := parseClassSpec(strings.TrimSuffix(tok, "+"))
+	if err != nil {
+		return nil, err
+	}
+	if !plus {
+		if cl.IsPair() {
+			return []Class{cl}, nil
+		}
+		return appendSuits(nil, cl, suits), nil
+	}
+	var out []Class
+	if cl.IsPair() {
+		for r := cl.Low; r <= hand.RankA; r++ {
+			out = append(out, Class{High: r, Low: r})
+		}
+		return out, nil
+	}
+	for l := cl.Low; l < cl.High; l++ {
+		out = appendSuits(out, Class{High: cl.High, Low: l}, suits)
+	}
+	return out, nil
+}
+
+// appendSuits appends the suited and/or offsuit class of cl's ranks ('s', 'o' or 0 for both).
+func appendSuits(out []Class, cl Class, suits byte) []Class {
+	if suits != 'o' {
+		out = append(out, Class{High: cl.High, Low: cl.Low, Suited: true})
+	}
+	if suits != 's' {
+		out = append(out, Class{High: cl.High, Low: cl.Low})
+	}
+	return out
+}
+
+// parseClassSpec parses two ranks and an optional 's' or 'o', e.g. "AK", "T9s", "QQ".
+// The ranks may come in either order.
+func parseClassSpec(s string) (Class, byte, error) {
+	if len(s) != 2 && len(s) != 3 {
+		return Class{}, 0, fmt.Errorf("invalid hand class: %q", s)
+	}
+	a := strings.IndexByte(rankChars, upper(s[0]))
+	b := strings.IndexByte(rankChars, upper(s[1]))
+	if a < 0 || b < 0 {
+		return Class{}, 0, fmt.Errorf("invalid hand class: %q", s)
+	}
+	if a < b {
+		a, b = b, a
+	}
+	var suits byte
+	if len(s) == 3 {
+		suits = s[2] | 0x20 // lower case
+		if (suits != 's' && suits != 'o') || a == b {
+			return Class{}, 0, fmt.Errorf("invalid hand class: %q", s)
+		}
+	}
+	return Class{High: a, Low: b}, suits, nil
+}
+
+func upper(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 0x20
+	}
+	return c
+}
+
+// String returns the range in compact notation: pairs, then suited and offsuit hands,
+// with runs of equally weighted classes folded into "TT+", "ATs+" and "A5s-A2s", and
+// combos that do not fill a class listed one by one. Parse(r.String()) equals r.
+func (r *Range) String() string {
+	var parts []string
+	var single []string
+	// full returns the weight shared by all combos of cl, or 0 if cl is not complete;
+	// combos of incomplete classes are listed individually.
+	full := func(cl Class) float64 {
+		combos := cl.Combos()
+		w := r.weights[combos[0]]
+		for _, c := range combos[1:] {
+			if r.weights[c] != w {
+				w = 0
+				break
+			}
+		}
+		if w == 0 {
+			for _, c := range combos {
+				if cw, ok := r.weights[c]; ok {
+					single = append(single, c.String()+weightSuffix(cw))
+				}
+			}
+		}
+		return w
+	}
+
+	// Pairs, from AA down.
+	var pw [13]float64
+	for rk := hand.RankA; rk >= hand.Rank2; rk-- {
+		pw[rk] = full(Class{High: rk, Low: rk})
+	}
+	for top := hand.RankA; top >= hand.Rank2; {
+		w := pw[top]
+		bottom := top
+		for bottom > hand.Rank2 && pw[bottom-1] == w {
+			bottom--
+		}
+		if w > 0 {
+			hi, lo := Class{High: top, Low: top}, Class{High: bottom, Low: bottom}
+			switch {
+			case top == bottom:
+				parts = append(parts, hi.String()+weightSuffix(w))
+			case top == hand.RankA:
+				parts = append(parts, lo.String()+"+"+weightSuffix(w))
+			default:
+				parts = append(parts, hi.String()+"-"+lo.String()+weightSuffix(w))
+			}
+		}
+		top = bottom - 1
+	}
+
+	// Suited, then offsuit hands by high card, kickers from the top.
+	for _, suited := range []bool{true, false} {
+		for h := hand.RankA; h >= hand.Rank3; h-- {
+			var kw [13]float64
+			for l := h - 1; l >= hand.Rank2; l-- {
+				kw[l] = full(Class{High: h, Low: l, Suited: suited})
+			}
+			for top := h - 1; top >= hand.Rank2; {
+				w := kw[top]
+				bottom := top
+				for bottom > hand.Rank2 && kw[bottom-1] == w {
+					bottom--
+				}
+				if w > 0 {
+					hi, lo := Class{High: h, Low: top, Suited: suited}, Class{High: h, Low: bottom, Suited: suited}
+					switch {
+					case top == bottom:
+						parts = append(parts, hi.String()+weightSuffix(w))
+					case top == h-1:
+						parts = append(parts, lo.String()+"+"+weightSuffix(w))
+					default:
+						parts = append(parts, hi.String()+"-"+lo.String()+weightSuffix(w))
+					}
+				}
+				top = bottom - 1
+			}
+		}
+	}
+	return strings.Join(append(parts, single...), ", ")
+}
+
+// weightSuffix returns ":w" for weights below 1.
+func weightSuffix(w float64) string {
+	if w == 1 {
+		return ""
+	}
+	return ":" + strconv.FormatFloat(w, 'g', -1, 64)
+}
@@ -0,0 +1,116 @@
+package ranges
+
+import (
+	"testing"
+
+	"texashold-backend/hand"
+)
+
+func TestParseCounts(t *testing.T) {
+	cases := map[string]int{
+		"AA":              6,
+		"TT+":             30,
+		"22-55":           24,
+		"55-22":           24,
+		"AKs":             4,
+		"AKo":             12,
+		"AK":              16,
+		"ATs+":            16,
+		"KTo+":            36,
+		"A2s-A5s":         16,
+		"AhKh":            1,
+		"10s Td":          0, // two tokens, neither a hand
+		"TT+, AKs, AhKd":  35,
+		"AA,AA":           6,
+		"QQ+ AKs:0.5 A5s": 26,
+	}
+	for s, want := range cases {
+		r, err := Parse(s)
+		if want == 0 {
+			if err == nil {
+				t.Errorf("Parse(%q): expected error", s)
+			}
+			continue
+		}
+		if err != nil || r.Len() != want {
+			t.Errorf("Parse(%q): %d combos, %v; want %d", s, lenOf(r), err, want)
+		}
+	}
+	for _, s := range []string{"AKx", "AAs", "A2s-K5s", "22-A5s", "AKs:0", "AKs:1.5", "AhAh", "Zz"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Parse(%q): expected error", s)
+		}
+	}
+}
+
+func lenOf(r *Range) int {
+	if r == nil {
+		return -1
+	}
+	return r.Len()
+}
+
+func TestWeightsAndBlockers(t *testing.T) {
+	r := MustParse("AKs:0.5, AhKh")
+	ah, kh := hand.Card{Suit: hand.SuitHeart, Rank: hand.RankA}, hand.Card{Suit: hand.SuitHeart, Rank: hand.RankK}
+	as, ks := hand.Card{Suit: hand.SuitSpade, Rank: hand.RankA}, hand.Card{Suit: hand.SuitSpade, Rank: hand.RankK}
+	if w := r.Weight(NewCombo(kh, ah)); w != 1 {
+		t.Errorf("AhKh weight = %v, want 1 (later token overrides)", w)
+	}
+	if w := r.Weight(NewCombo(as, ks)); w != 0.5 {
+		t.Errorf("AsKs weight = %v, want 0.5", w)
+	}
+	if tw := r.TotalWeight(); tw != 2.5 {
+		t.Errorf("TotalWeight = %v, want 2.5", tw)
+	}
+	blocked := MustParse("AK, QQ").Without(hand.NewCardSet(ah))
+	if blocked.Len() != 12+6 {
+		t.Errorf("AK, QQ without Ah: %d combos, want 18", blocked.Len())
+	}
+	for _, c := range blocked.Combos() {
+		if c.A == ah || c.B == ah {
+			t.Errorf("blocked combo %v still in range", c.Combo)
+		}
+	}
+}
+
+func TestClasses(t *testing.T) {
+	classes := Classes()
+	if len(classes) != 169 {
+		t.Fatalf("%d classes, want 169", len(classes))
+	}
+	total := 0
+	for _, cl := range classes {
+		total += len(cl.Combos())
+	}
+	if total != 1326 {
+		t.Errorf("%d combos over all classes, want 1326", total)
+	}
+}
+
+func TestStringRoundTrip(t *testing.T) {
+	cases := map[string]string{
+		"TT+":                         "TT+",
+		"AA, KK, QQ, 55, 44, 33":      "QQ+, 55-33",
+		"AKs, AQs, AJs, ATs, A5s-A2s": "ATs+, A5s-A2s",
+		"AKo:0.5, KQs":                "KQs, AKo:0.5",
+		"AhKh, 22":                    "22, AhKh",
+		"AK":                          "AKs, AKo",
+	}
+	for in, want := range cases {
+		r := MustParse(in)
+		if got := r.String(); got != want {
+			t.Errorf("Parse(%q).String() = %q, want %q", in, got, want)
+		}
+	}
+	r := MustParse("22+, A2s+:0.25, KTo-KQo, AhKd, 7c6c:0.5, 76s:0.5")
+	back := MustParse(r.String())
+	if back.Len() != r.Len() {
+		t.Fatalf("round trip %q: %d combos, want %d", r.String(), back.Len(), r.Len())
+	}
+	for _, c := range r.Combos() {
+		if back.Weight(c.Combo) != c.Weight {
+			t.Errorf("round trip %q: %v weight %v, want %v", r.String(), c.Combo, back.Weight(c.Combo), c.Weight)
+		}
+	}
+}